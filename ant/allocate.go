@@ -0,0 +1,69 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/devicetype"
+)
+
+// AllocateChannel assigns the lowest free channel number which is not reserved
+// to the device type dev. Returns ErrNoFreeChannels if every channel of the
+// stick is in use or reserved. The number is free again once the Channel is unassigned.
+func (a *Antbuffer) AllocateChannel(dev *devicetype.Antdevicetype) (*Channel, error) {
+	return a.AllocateChannelContext(context.Background(), dev)
+}
+
+// AllocateChannelContext is AllocateChannel with the configuration sequence bounded by ctx.
+func (a *Antbuffer) AllocateChannelContext(ctx context.Context, dev *devicetype.Antdevicetype) (*Channel, error) {
+	a.channelsLock.Lock()
+	number, ok := a.freeChannel()
+	if !ok {
+		a.channelsLock.Unlock()
+		return nil, ErrNoFreeChannels
+	}
+	c := a.newChannel(number, dev)
+	a.channelsLock.Unlock()
+
+	return a.assign(ctx, c)
+}
+
+// freeChannel finds the lowest channel number neither in use nor reserved.
+// The channelsLock must be held.
+func (a *Antbuffer) freeChannel() (byte, bool) {
+	for number := byte(0); number < a.capabilities.MaxChannels; number++ {
+		if _, ok := a.channels[number]; ok {
+			continue
+		}
+		if a.reserved[number] {
+			continue
+		}
+		return number, true
+	}
+	return 0, false
+}
+
+// ReserveChannels keeps the given channel numbers out of AllocateChannel, for
+// callers which need specific numbers. Reserved numbers can still be claimed
+// with AssignChannel or SetupChannel.
+func (a *Antbuffer) ReserveChannels(numbers ...byte) error {
+	for _, number := range numbers {
+		if number >= a.capabilities.MaxChannels {
+			return ErrChannelOutOfRange
+		}
+	}
+
+	a.channelsLock.Lock()
+	defer a.channelsLock.Unlock()
+	for _, number := range numbers {
+		a.reserved[number] = true
+	}
+	return nil
+}
+
+// UnreserveChannels returns reserved channel numbers to AllocateChannel.
+func (a *Antbuffer) UnreserveChannels(numbers ...byte) {
+	a.channelsLock.Lock()
+	defer a.channelsLock.Unlock()
+	for _, number := range numbers {
+		delete(a.reserved, number)
+	}
+}
@@ -0,0 +1,433 @@
+// Package ant is a driver for ant sticks and modules.
+//
+// The Antbuffer buffers and manages the ongoing communication between the host and the ant stick.
+// The Antbuffer can be told to set up and listen on a channel. By default, the
+// Antbuffer will keep that listen open forever until the end of the program.
+package ant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The Antbuffer is the point of control over the serial interface to the ant stick.
+type Antbuffer struct {
+	transport            Transport
+	framer               *protocol.Framer
+	readChan             chan *protocol.Antpacket
+	writeChan            chan *writeRequest
+	handlersLock         sync.RWMutex
+	channelListenners    [][]*handler
+	anyChannelListenners []*handler
+	unclaimedListenners  []*handler
+	pendingLock          sync.Mutex
+	pending              []*replyWaiter
+	channelsLock         sync.Mutex
+	channels             map[byte]*Channel
+	reserved             map[byte]bool
+	networksLock         sync.Mutex
+	networks             map[string]*network
+	ready                int32
+	extended             int32
+	restoreLock          sync.Mutex
+	resets               chan ResetEvent
+	replyTimeout         time.Duration
+	waitTimeout          time.Duration
+	capabilities         Capabilities
+	closeTimeout         time.Duration
+	transferTimeout      time.Duration
+	serialRetries        int
+	resetOnClose         bool
+	quit                 chan struct{}
+	quitOnce             sync.Once
+	daemons              sync.WaitGroup
+	done                 chan struct{}
+	closeOnce            sync.Once
+	errs                 chan error
+	errLock              sync.Mutex
+	err                  error
+}
+
+// NewAntbuffer creates a new Antbuffer communicating over the given transport
+// and registers the given network key as the NetworkANTPlus network unless nil.
+// The transport is closed if the stick cannot be initialised.
+func NewAntbuffer(transport Transport, networkKey []byte, opts ...Option) (*Antbuffer, error) {
+	return NewAntbufferContext(context.Background(), transport, networkKey, opts...)
+}
+
+// NewAntbufferContext is NewAntbuffer with the stick's initialisation bounded by ctx.
+func NewAntbufferContext(ctx context.Context, transport Transport, networkKey []byte, opts ...Option) (*Antbuffer, error) {
+	// Create read channel
+	readChan := make(chan *protocol.Antpacket, 20)
+	// Create write channel
+	writeChan := make(chan *writeRequest, 20)
+
+	// Initialize Antbuffer
+	antbuf := &Antbuffer{
+		transport:       transport,
+		framer:          &protocol.Framer{},
+		readChan:        readChan,
+		writeChan:       writeChan,
+		channels:        make(map[byte]*Channel),
+		reserved:        make(map[byte]bool),
+		networks:        map[string]*network{NetworkPublic: {number: 0}},
+		replyTimeout:    DefaultReplyTimeout,
+		waitTimeout:     DefaultWaitTimeout,
+		closeTimeout:    DefaultCloseTimeout,
+		transferTimeout: DefaultTransferTimeout,
+		serialRetries:   DefaultSerialRetries,
+		quit:            make(chan struct{}),
+		done:            make(chan struct{}),
+		errs:            make(chan error, 20),
+		resets:          make(chan ResetEvent, 5),
+	}
+	for _, opt := range opts {
+		opt(antbuf)
+	}
+
+	// Launch listener and writer daemons
+	antbuf.daemons.Add(2)
+	go antbuf.readDaemon()
+	go antbuf.writeDaemon()
+	go func() {
+		antbuf.daemons.Wait()
+		close(antbuf.done)
+	}()
+
+	err := antbuf.initialise(ctx, networkKey)
+	if err != nil {
+		antbuf.stop()
+		return nil, err
+	}
+	atomic.StoreInt32(&antbuf.ready, 1)
+
+	return antbuf, nil
+}
+
+// initialise resets the stick, sizes the channel tables to its capabilities and loads the ant plus network key.
+func (a *Antbuffer) initialise(ctx context.Context, networkKey []byte) error {
+	// Reset
+	_, err := a.GenSendAndWaitContext(ctx, protocol.SystemReset, 0)
+	if err != nil {
+		return err
+	}
+
+	// Find out what the stick can do
+	a.capabilities, err = a.requestCapabilities(ctx)
+	if err != nil {
+		return err
+	}
+	a.handlersLock.Lock()
+	a.channelListenners = make([][]*handler, a.capabilities.MaxChannels)
+	a.handlersLock.Unlock()
+
+	// Load the ant plus network key, which takes network 1
+	if networkKey == nil {
+		return nil
+	}
+	_, err = a.SetNetworkContext(ctx, NetworkANTPlus, networkKey)
+	return err
+}
+
+// SetupChannel will begin listening for the device specified by dev, initializing it on given channel.
+// Returns the open Channel, whose Events receive every packet and event generated on it.
+func (a *Antbuffer) SetupChannel(channel byte, dev *devicetype.Antdevicetype) (*Channel, error) {
+	return a.SetupChannelContext(context.Background(), channel, dev)
+}
+
+// SetupChannelContext is SetupChannel with the whole configuration sequence bounded by ctx.
+func (a *Antbuffer) SetupChannelContext(ctx context.Context, channel byte, dev *devicetype.Antdevicetype) (*Channel, error) {
+	c, err := a.AssignChannelContext(ctx, channel, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open Channel!
+	err = c.OpenContext(ctx)
+	if err != nil {
+		c.Unassign()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// readDaemon is the goroutine which holds the read side of the transport.
+// It reassembles antpackets from the incoming bytes and forwards them for distribution.
+func (a *Antbuffer) readDaemon() {
+	defer a.daemons.Done()
+
+	// Read until the transport is closed or the stick is gone
+	for {
+		buf, err := a.transport.ReadFrame()
+		if err == ErrTransportTimeout {
+			// Timeout, unless the Antbuffer stopped in the meantime
+			select {
+			case <-a.quit:
+				return
+			default:
+				continue
+			}
+		} else if err == ErrTransportClosed {
+			// Expected from Close; otherwise the transport was pulled from under us
+			select {
+			case <-a.quit:
+			default:
+				derr := &DaemonError{ErrorDeviceGone, "read", fmt.Errorf("%w: %v", ErrDeviceGone, err)}
+				a.report(derr)
+				a.fail(derr)
+			}
+			return
+		} else if err != nil {
+			derr := classify("read", err)
+			a.report(derr)
+			if derr.Kind == ErrorDeviceGone {
+				a.fail(derr)
+				return
+			}
+			// Don't spin on a persistent failure
+			select {
+			case <-time.After(transientBackoff):
+				continue
+			case <-a.quit:
+				return
+			}
+		}
+
+		// Send out every complete packet, replies first to whoever awaits them
+		discarded := a.framer.DiscardedBytes()
+		a.framer.Write(buf)
+		for pkt := a.framer.Next(); pkt != nil; pkt = a.framer.Next() {
+			// Nothing downstream has to cope with packets of the wrong length
+			if err := pkt.ValidateLength(); err != nil {
+				a.report(&DaemonError{ErrorProtocol, "read", fmt.Errorf("%w: 0x%02X with %d data bytes", err, pkt.ID, len(pkt.Data))})
+				continue
+			}
+			a.trackChannelEvent(pkt)
+			if a.deliverReply(pkt) {
+				continue
+			}
+			switch pkt.ID {
+			case protocol.StartupMessage:
+				a.unsolicitedReset(pkt)
+			case protocol.SerialErrorMessage:
+				a.serialError(pkt)
+			}
+			a.dispatch(pkt)
+		}
+		if n := a.framer.DiscardedBytes() - discarded; n > 0 {
+			a.report(&DaemonError{ErrorProtocol, "read", fmt.Errorf("%w: discarded %d bytes", ErrStreamCorrupt, n)})
+		}
+	}
+}
+
+// serialError reports a message the stick could not read and fails the
+// request awaiting its reply, which will never come.
+func (a *Antbuffer) serialError(pkt *protocol.Antpacket) {
+	serr, err := protocol.DecodeSerialError(pkt)
+	if err != nil {
+		return
+	}
+	a.report(&DaemonError{ErrorProtocol, "write", serr})
+	a.rejectReplies(serr)
+}
+
+// DiscardedBytes returns the number of bytes received from the stick which did
+// not form a valid packet. A growing count indicates a noisy or misconfigured link.
+func (a *Antbuffer) DiscardedBytes() uint64 {
+	return a.framer.DiscardedBytes()
+}
+
+// A writeRequest is a packet queued for the write daemon.
+// The result of writing it is reported on done.
+type writeRequest struct {
+	pkt  *protocol.Antpacket
+	done chan error
+}
+
+// writeDaemon is the goroutine which holds the write side of the transport.
+// All outbound packets are serialised through it in the order they were queued.
+func (a *Antbuffer) writeDaemon() {
+	defer a.daemons.Done()
+
+	for {
+		select {
+		case req := <-a.writeChan:
+			outBuf := new(bytes.Buffer)
+			_, err := req.pkt.ToBinary(outBuf)
+			if err == nil {
+				err = a.transport.WriteFrame(outBuf.Bytes())
+			}
+			req.done <- err
+			if err != nil && err != ErrTransportClosed {
+				derr := classify("write", err)
+				a.report(derr)
+				if derr.Kind == ErrorDeviceGone {
+					a.fail(derr)
+					return
+				}
+			}
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+// GenSendAndWait - Generate an antpacket, send and await reply
+//
+// Only the reply to the generated packet is returned; unrelated packets arriving
+// in the meantime are left for Wait. A reply carrying a non-zero response code
+// is returned as a *ResponseError. SendAndWait does the same for the typed
+// messages of the message package; this is the escape hatch for raw bytes.
+func (a *Antbuffer) GenSendAndWait(pktdetails ...byte) (*protocol.Antpacket, error) {
+	return a.GenSendAndWaitContext(context.Background(), pktdetails...)
+}
+
+// GenSendAndWaitContext is GenSendAndWait honouring the deadline and cancellation of ctx.
+// The reply timeout of the Antbuffer still applies when ctx has a later deadline.
+//
+// If the stick reports a serial error while the reply is awaited, the packet
+// is sent again up to the Antbuffer's serial retries, after which the
+// *protocol.SerialError is returned.
+func (a *Antbuffer) GenSendAndWaitContext(ctx context.Context, pktdetails ...byte) (*protocol.Antpacket, error) {
+	pkt, err := protocol.GenerateAntpacket(pktdetails[0], pktdetails[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	return a.sendPacketAndWait(ctx, pkt)
+}
+
+// SendAndWait marshals msg, sends it and awaits the reply as GenSendAndWait does.
+func (a *Antbuffer) SendAndWait(msg message.Message) (*protocol.Antpacket, error) {
+	return a.SendAndWaitContext(context.Background(), msg)
+}
+
+// SendAndWaitContext is SendAndWait honouring the deadline and cancellation of ctx.
+func (a *Antbuffer) SendAndWaitContext(ctx context.Context, msg message.Message) (*protocol.Antpacket, error) {
+	pkt, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return a.sendPacketAndWait(ctx, pkt)
+}
+
+// sendPacketAndWait sends pkt and awaits its reply, resending after serial errors.
+func (a *Antbuffer) sendPacketAndWait(ctx context.Context, pkt *protocol.Antpacket) (*protocol.Antpacket, error) {
+	// TODO: Debug flag for this
+	log.Println("OUT: ", pkt.Describe(protocol.HostToANT))
+
+	for attempt := 0; ; attempt++ {
+		reply, err := a.sendAndWait(ctx, pkt)
+		var serr *protocol.SerialError
+		if errors.As(err, &serr) && attempt < a.serialRetries {
+			log.Println("Resending after serial error: ", pkt.Describe(protocol.HostToANT))
+			continue
+		}
+		return reply, err
+	}
+}
+
+// sendAndWait sends pkt once and waits for its reply.
+func (a *Antbuffer) sendAndWait(ctx context.Context, pkt *protocol.Antpacket) (*protocol.Antpacket, error) {
+	// Listen for the reply before it can arrive
+	waiter := a.expectReply(pkt)
+	defer a.cancelReply(waiter)
+
+	// Send
+	err := a.SendContext(ctx, pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait
+	timeout := time.NewTimer(a.replyTimeout)
+	defer timeout.Stop()
+	select {
+	case reply := <-waiter.reply:
+		log.Printf("IN: %v\n", reply.Describe(protocol.ANTToHost))
+		if err = responseError(reply); err != nil {
+			return nil, err
+		}
+		return reply, nil
+	case err = <-waiter.rejected:
+		return nil, err
+	case <-timeout.C:
+		return nil, ErrAntTimedout
+	case <-a.quit:
+		return nil, ErrAntbufferClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Send queues a packet for the write daemon and blocks until it has been written.
+//
+// Packets from a single caller are written in the order they are sent. When the
+// queue is full Send blocks until there is room. Returns the error from writing
+// to the transport, if any. Messages only the stick sends, such as the startup
+// message, are refused with protocol.ErrWrongDirection.
+func (a *Antbuffer) Send(pkt *protocol.Antpacket) error {
+	return a.SendContext(context.Background(), pkt)
+}
+
+// SendContext is Send giving up when ctx is done. A packet already handed to
+// the write daemon may still be written after SendContext returns.
+func (a *Antbuffer) SendContext(ctx context.Context, pkt *protocol.Antpacket) error {
+	// Refuse messages only the stick sends
+	if c, ok := protocol.MsgClasses[pkt.ID]; ok && c.Direction&protocol.HostToANT == 0 {
+		return protocol.ErrWrongDirection
+	}
+
+	req := &writeRequest{pkt, make(chan error, 1)}
+	select {
+	case a.writeChan <- req:
+	case <-a.quit:
+		return ErrAntbufferClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-a.quit:
+		return ErrAntbufferClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait blocks while listening for a packet which no registered handler claimed.
+// Returns ErrAntTimedout if nothing arrives within the wait timeout.
+// This function will be deprecated soon.
+func (a *Antbuffer) Wait() (*protocol.Antpacket, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.waitTimeout)
+	defer cancel()
+
+	pkt, err := a.WaitContext(ctx)
+	if err == context.DeadlineExceeded {
+		return nil, ErrAntTimedout
+	}
+	return pkt, err
+}
+
+// WaitContext is Wait blocking until ctx is done rather than for the wait timeout.
+func (a *Antbuffer) WaitContext(ctx context.Context) (*protocol.Antpacket, error) {
+	log.Println("Waiting for reply...")
+	select {
+	case pkt := <-a.readChan:
+		log.Printf("IN: %v\n", pkt.Describe(protocol.ANTToHost))
+		return pkt, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,907 @@
+package ant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStick simulates an ant stick on the far end of a loopback transport.
+// Each received command is answered with the packets returned by replies,
+// and the ids of received packets are recorded.
+type fakeStick struct {
+	transport Transport
+	received  chan *protocol.Antpacket
+	replies   func(cmd *protocol.Antpacket) []*protocol.Antpacket
+}
+
+// newFakeStick starts a simulated stick with 8 channels and 3 networks. If
+// replies is nil every command is acknowledged with RESPONSE_NO_ERROR, a
+// SystemReset is answered with a StartupMessage, a CloseChannel is followed by
+// EVENT_CHANNEL_CLOSED and the Capabilities, ANTVersion and SerialNumber can
+// be requested.
+func newFakeStick(t *testing.T, replies func(cmd *protocol.Antpacket) []*protocol.Antpacket) (*fakeStick, Transport) {
+	if replies == nil {
+		replies = defaultReplies
+	}
+	host, stick := NewLoopback()
+	f := &fakeStick{stick, make(chan *protocol.Antpacket, 100), replies}
+	go f.run(t)
+	return f, host
+}
+
+func defaultReplies(cmd *protocol.Antpacket) []*protocol.Antpacket {
+	if cmd.ID == protocol.SystemReset {
+		reply, _ := protocol.GenerateAntpacket(protocol.StartupMessage, 0x20)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.Capabilities {
+		// 8 channels, 3 networks, extended messages
+		reply, _ := protocol.GenerateAntpacket(protocol.Capabilities, 8, 3, 0x00, 0xBA, 0x36, 0x00)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.ANTVersion {
+		reply, _ := protocol.GenerateAntpacket(protocol.ANTVersion, 'A', 'J', 'K', '1', '.', '0', '4', 'R', 'A', 'F', 0)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.SerialNumber {
+		reply, _ := protocol.GenerateAntpacket(protocol.SerialNumber, 0x78, 0x56, 0x34, 0x12)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.CloseChannel {
+		closed, _ := protocol.GenerateAntpacket(protocol.ChannelResponseOrEvent, cmd.Data[0], 0x01, byte(protocol.EventChannelClosed))
+		return []*protocol.Antpacket{response(cmd, 0), closed}
+	}
+	return []*protocol.Antpacket{response(cmd, 0)}
+}
+
+// response generates the ChannelResponseOrEvent answering cmd with code.
+func response(cmd *protocol.Antpacket, code byte) *protocol.Antpacket {
+	reply, _ := protocol.GenerateAntpacket(protocol.ChannelResponseOrEvent, cmd.Data[0], cmd.ID, code)
+	return reply
+}
+
+func (f *fakeStick) run(t *testing.T) {
+	for {
+		frame, err := f.transport.ReadFrame()
+		if err != nil {
+			return
+		}
+		pkt, err := protocol.ReadAntpacket(frame)
+		if err != nil {
+			t.Error("Stick received bad packet, ", err)
+			return
+		}
+		f.received <- pkt
+
+		for _, reply := range f.replies(pkt) {
+			f.send(reply)
+		}
+	}
+}
+
+func (f *fakeStick) send(pkt *protocol.Antpacket) {
+	buf := new(bytes.Buffer)
+	pkt.ToBinary(buf)
+	f.transport.WriteFrame(buf.Bytes())
+}
+
+// encodedPacket generates a packet in line format.
+func encodedPacket(class byte, args ...byte) []byte {
+	pkt, _ := protocol.GenerateAntpacket(class, args...)
+	buf := new(bytes.Buffer)
+	pkt.ToBinary(buf)
+	return buf.Bytes()
+}
+
+// ids returns the ids of every packet the stick has received so far.
+func (f *fakeStick) ids() []byte {
+	var ids []byte
+	for {
+		select {
+		case pkt := <-f.received:
+			ids = append(ids, pkt.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestSetupChannelSequence(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	_, err = antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	expected := []byte{
+		protocol.SystemReset,
+		protocol.RequestMessage,
+		protocol.SetNetwork,
+		protocol.AssignChannel,
+		protocol.SetChannelRFFrequency,
+		protocol.SetChannelID,
+		protocol.SetChannelPeriod,
+		protocol.SetSearchTimeout,
+		protocol.OpenChannel,
+	}
+	if got := stick.ids(); !bytes.Equal(got, expected) {
+		t.Fatalf("Unexpected command sequence % X, expected % X", got, expected)
+	}
+}
+
+func TestGenSendAndWaitSkipsUnrelated(t *testing.T) {
+	broadcast, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		// Traffic from an open channel arrives ahead of every reply
+		return append([]*protocol.Antpacket{broadcast}, defaultReplies(cmd)...)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	reply, err := antbuf.GenSendAndWait(protocol.AssignChannel, 0x02, 0x00, 0x01)
+	if err != nil {
+		t.Fatal("Error assigning channel, ", err)
+	}
+	if reply.ID != protocol.ChannelResponseOrEvent || reply.Data[0] != 0x02 || reply.Data[1] != protocol.AssignChannel {
+		t.Fatal("Unexpected reply, ", reply)
+	}
+
+	// The broadcasts are left for their normal consumer
+	pkt, err := antbuf.Wait()
+	if err != nil || pkt.ID != protocol.BroadcastData {
+		t.Fatal("Broadcast was not passed on, ", pkt, err)
+	}
+}
+
+func TestTruncatedReply(t *testing.T) {
+	truncated := &protocol.Antpacket{Sync: protocol.SyncByte, MsgLen: 1, ID: protocol.ChannelResponseOrEvent, Data: []byte{0x01}}
+	truncated.SetChecksum()
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.OpenChannel {
+			return append([]*protocol.Antpacket{truncated}, defaultReplies(cmd)...)
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	// The short response is not taken for the reply
+	reply, err := antbuf.GenSendAndWait(protocol.OpenChannel, 0x01)
+	if err != nil {
+		t.Fatal("Error opening channel, ", err)
+	}
+	if len(reply.Data) != 3 {
+		t.Fatal("Unexpected reply, ", reply)
+	}
+
+	// Nor is any other short packet
+	empty := &protocol.Antpacket{ID: protocol.ChannelStatus}
+	for _, sent := range []*protocol.Antpacket{
+		{ID: protocol.OpenChannel, Data: []byte{0x01}},
+		{ID: protocol.RequestMessage, Data: []byte{0x01, protocol.ChannelStatus}},
+		{ID: protocol.RequestMessage},
+	} {
+		match := replyMatcher(sent)
+		if match(truncated) || match(empty) {
+			t.Fatal("Short packet matched the reply to ", sent)
+		}
+	}
+}
+
+func TestGenSendAndWaitResponseError(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.OpenChannel {
+			return []*protocol.Antpacket{response(cmd, byte(protocol.ChannelInWrongState))}
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	_, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x03)
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatal("Expected a ResponseError, got ", err)
+	}
+	if respErr.Channel != 0x03 || respErr.MessageID != protocol.OpenChannel || respErr.Code != protocol.ChannelInWrongState {
+		t.Fatal("Unexpected ResponseError, ", respErr)
+	}
+	if !errors.Is(err, protocol.ChannelInWrongState) {
+		t.Fatal("ResponseError does not match its code with errors.Is")
+	}
+}
+
+func TestDispatchByChannel(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	first, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel 1, ", err)
+	}
+	second, err := antbuf.SetupChannel(0x02, devicetype.Weighscale)
+	if err != nil {
+		t.Fatal("Error setting up channel 2, ", err)
+	}
+	broadcasts := make(chan *protocol.Antpacket, 5)
+	unregister, err := antbuf.RegisterHandler(AnyChannel, protocol.BroadcastData, broadcasts)
+	if err != nil {
+		t.Fatal("Error registering handler, ", err)
+	}
+
+	for _, channel := range []byte{0x02, 0x01, 0x03} {
+		pkt, _ := protocol.GenerateAntpacket(protocol.BroadcastData, channel, 0, 0, 0, 0, 0, 0, 0, channel)
+		stick.send(pkt)
+	}
+
+	if ev := <-first.Events(); ev.Packet.Data[0] != 0x01 {
+		t.Fatal("Channel 1 received packet for channel ", ev.Packet.Data[0])
+	}
+	if ev := <-second.Events(); ev.Packet.Data[0] != 0x02 {
+		t.Fatal("Channel 2 received packet for channel ", ev.Packet.Data[0])
+	}
+	for i := 0; i < 3; i++ {
+		if pkt := <-broadcasts; pkt.ID != protocol.BroadcastData {
+			t.Fatal("Class handler received ", pkt)
+		}
+	}
+
+	// Once unregistered, channel 3 traffic is claimed by nobody
+	unregister()
+	pkt, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x03, 0, 0, 0, 0, 0, 0, 0, 0)
+	stick.send(pkt)
+	pkt, err = antbuf.Wait()
+	if err != nil || pkt.Data[0] != 0x03 {
+		t.Fatal("Unclaimed packet did not reach Wait, ", pkt, err)
+	}
+	if len(broadcasts) != 0 {
+		t.Fatal("Unregistered handler still received packets")
+	}
+}
+
+func TestRegisterUnclaimedHandler(t *testing.T) {
+	stick, transport := newFakeStick(t, channelReplies)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	defer antbuf.Close()
+
+	first, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel 1, ", err)
+	}
+	acks := make(chan *protocol.Antpacket, 5)
+	if _, err = antbuf.RegisterHandler(AnyChannel, protocol.AcknowledgeData, acks); err != nil {
+		t.Fatal("Error registering handler, ", err)
+	}
+	unclaimed := make(chan *protocol.Antpacket, 5)
+	unregister := antbuf.RegisterUnclaimedHandler(unclaimed)
+
+	// Channel 1 and acknowledged data are claimed, channel 3 broadcasts are not
+	broadcast, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x01, 0, 0, 0, 0, 0, 0, 0, 0)
+	ack, _ := protocol.GenerateAntpacket(protocol.AcknowledgeData, 0x03, 0, 0, 0, 0, 0, 0, 0, 0)
+	stray, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x03, 0, 0, 0, 0, 0, 0, 0, 0)
+	for _, pkt := range []*protocol.Antpacket{broadcast, ack, stray} {
+		stick.send(pkt)
+	}
+
+	if ev := <-first.Events(); ev.Packet.Data[0] != 0x01 {
+		t.Fatal("Channel 1 received packet for channel ", ev.Packet.Data[0])
+	}
+	if pkt := <-acks; pkt.ID != protocol.AcknowledgeData {
+		t.Fatal("Class handler received ", pkt)
+	}
+	if pkt := <-unclaimed; pkt.ID != protocol.BroadcastData || pkt.Data[0] != 0x03 {
+		t.Fatal("Unclaimed handler received ", pkt)
+	}
+	if len(unclaimed) != 0 {
+		t.Fatal("Unclaimed handler received claimed packets")
+	}
+
+	// Once unregistered, unclaimed packets are left for Wait again
+	unregister()
+	later, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x03, 0, 0, 0, 0, 0, 0, 0, 0x01)
+	stick.send(later)
+	pkt, err := antbuf.Wait()
+	if err != nil {
+		t.Fatal("Unclaimed packet did not reach Wait, ", err)
+	}
+	if pkt.Data[8] != 0x01 {
+		t.Fatal("Packet taken by the unclaimed handler also reached Wait")
+	}
+	if len(unclaimed) != 0 {
+		t.Fatal("Unregistered handler still received packets")
+	}
+}
+
+func TestRegisterHandlerOutOfRange(t *testing.T) {
+	antbuf := &Antbuffer{channelListenners: make([][]*handler, 6)}
+	_, err := antbuf.RegisterHandler(6, AnyClass, make(chan *protocol.Antpacket))
+	if err != ErrChannelOutOfRange {
+		t.Fatal("Expected ErrChannelOutOfRange, got ", err)
+	}
+}
+
+func TestConcurrentSetup(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	errs := make(chan error)
+	for channel := byte(0); channel < 4; channel++ {
+		go func(channel byte) {
+			_, err := antbuf.SetupChannel(channel, devicetype.Heartrate)
+			errs <- err
+		}(channel)
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal("Error setting up channel concurrently, ", err)
+		}
+	}
+}
+
+func TestSendReportsWriteError(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	stick.transport.Close()
+	pkt, _ := protocol.GenerateAntpacket(protocol.OpenChannel, 0x01)
+	if err := antbuf.Send(pkt); err != ErrTransportClosed {
+		t.Fatal("Expected ErrTransportClosed from Send, got ", err)
+	}
+}
+
+func TestSendRefusesStickMessages(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	pkt, _ := protocol.GenerateAntpacket(protocol.StartupMessage, 0x00)
+	if err := antbuf.Send(pkt); err != protocol.ErrWrongDirection {
+		t.Fatal("Sent a startup message, ", err)
+	}
+	if _, err := antbuf.SendAndWait(&message.Capabilities{}); err != protocol.ErrWrongDirection {
+		t.Fatal("Sent capabilities, ", err)
+	}
+	// Set Channel ID shares its id with a reply
+	pkt, _ = (&message.SetChannelID{Channel: 0}).Marshal()
+	if err := antbuf.Send(pkt); err != nil {
+		t.Fatal("Error sending set channel id, ", err)
+	}
+}
+
+func TestSetupChannelContextCancel(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		// The stick goes silent once configuration begins
+		if cmd.ID == protocol.AssignChannel {
+			return nil
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8), WithReplyTimeout(time.Minute))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = antbuf.SetupChannelContext(ctx, 0x01, devicetype.Heartrate)
+	if err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got ", err)
+	}
+}
+
+func TestReplyTimeout(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.OpenChannel {
+			return nil
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8), WithReplyTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	_, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01)
+	if err != ErrAntTimedout {
+		t.Fatal("Expected ErrAntTimedout, got ", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8), WithResetOnClose(true))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	for _, channel := range []byte{0x02, 0x01} {
+		if _, err = antbuf.SetupChannel(channel, devicetype.Heartrate); err != nil {
+			t.Fatal("Error setting up channel, ", err)
+		}
+	}
+	stick.ids()
+
+	if err = antbuf.Close(); err != nil {
+		t.Fatal("Error closing antbuffer, ", err)
+	}
+
+	expected := []byte{
+		protocol.CloseChannel,
+		protocol.CloseChannel,
+		protocol.UnassignChannel,
+		protocol.UnassignChannel,
+		protocol.SystemReset,
+	}
+	if got := stick.ids(); !bytes.Equal(got, expected) {
+		t.Fatalf("Unexpected teardown sequence % X, expected % X", got, expected)
+	}
+
+	// The transport is released and nothing more can be sent
+	if _, err = stick.transport.ReadFrame(); err != ErrTransportClosed {
+		t.Fatal("Transport was not closed, ", err)
+	}
+	pkt, _ := protocol.GenerateAntpacket(protocol.OpenChannel, 0x01)
+	if err = antbuf.Send(pkt); err != ErrAntbufferClosed {
+		t.Fatal("Expected ErrAntbufferClosed from Send, got ", err)
+	}
+	if err = antbuf.Close(); err != ErrAntbufferClosed {
+		t.Fatal("Expected ErrAntbufferClosed from second Close, got ", err)
+	}
+	<-antbuf.Done()
+	if antbuf.Err() != ErrAntbufferClosed {
+		t.Fatal("Expected Err to be ErrAntbufferClosed after Close, got ", antbuf.Err())
+	}
+}
+
+func TestCloseChannelClosedBySearchTimeout(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.CloseChannel {
+			return []*protocol.Antpacket{response(cmd, byte(protocol.ChannelInWrongState))}
+		}
+		return defaultReplies(cmd)
+	})
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	if _, err = antbuf.SetupChannel(0x01, devicetype.Heartrate); err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+	stick.ids()
+
+	if err = antbuf.Close(); err != nil {
+		t.Fatal("Error closing antbuffer, ", err)
+	}
+	expected := []byte{protocol.CloseChannel, protocol.UnassignChannel}
+	if got := stick.ids(); !bytes.Equal(got, expected) {
+		t.Fatalf("Unexpected teardown sequence % X, expected % X", got, expected)
+	}
+}
+
+// flakyTransport injects read errors into a transport. An injected error is
+// returned by the read after the one in progress.
+type flakyTransport struct {
+	Transport
+	readErrs chan error
+}
+
+func (f *flakyTransport) ReadFrame() ([]byte, error) {
+	select {
+	case err := <-f.readErrs:
+		return nil, err
+	default:
+	}
+	return f.Transport.ReadFrame()
+}
+
+// vanishingTransport loses the stick once gone is set: writes fail and reads
+// only time out, as a serial port left behind by a USB adapter may.
+type vanishingTransport struct {
+	Transport
+	gone int32
+}
+
+func (v *vanishingTransport) ReadFrame() ([]byte, error) {
+	if atomic.LoadInt32(&v.gone) != 0 {
+		time.Sleep(time.Millisecond)
+		return nil, ErrTransportTimeout
+	}
+	return v.Transport.ReadFrame()
+}
+
+func (v *vanishingTransport) WriteFrame(frame []byte) error {
+	if atomic.LoadInt32(&v.gone) != 0 {
+		return fmt.Errorf("%w: unplugged", ErrDeviceGone)
+	}
+	return v.Transport.WriteFrame(frame)
+}
+
+// nextDaemonError waits for the next error reported by antbuf.
+func nextDaemonError(t *testing.T, antbuf *Antbuffer) *DaemonError {
+	select {
+	case err := <-antbuf.Errors():
+		var derr *DaemonError
+		if !errors.As(err, &derr) {
+			t.Fatal("Reported error is not a DaemonError, ", err)
+		}
+		return derr
+	case <-time.After(time.Second):
+		t.Fatal("No error reported")
+	}
+	return nil
+}
+
+func TestReadErrorReporting(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	flaky := &flakyTransport{transport, make(chan error, 1)}
+
+	antbuf, err := NewAntbuffer(flaky, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	broadcast, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	// A transient failure is reported and the Antbuffer carries on
+	flaky.readErrs <- errors.New("babble")
+	stick.send(broadcast)
+	if derr := nextDaemonError(t, antbuf); derr.Kind != ErrorTransient || derr.Op != "read" {
+		t.Fatal("Unexpected error, ", derr)
+	}
+	if _, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01); err != nil {
+		t.Fatal("Antbuffer did not survive a transient error, ", err)
+	}
+
+	// Garbage on the line is a protocol error
+	stick.transport.WriteFrame([]byte{0x00, 0x01, 0x02})
+	if derr := nextDaemonError(t, antbuf); derr.Kind != ErrorProtocol || !errors.Is(derr, ErrStreamCorrupt) {
+		t.Fatal("Unexpected error, ", derr)
+	}
+
+	// So is a packet too short for its message class, which goes no further
+	if pkt, err := antbuf.Wait(); err != nil || pkt.ID != protocol.BroadcastData {
+		t.Fatal("Broadcast was not passed on, ", pkt, err)
+	}
+	truncated := &protocol.Antpacket{Sync: protocol.SyncByte, MsgLen: 1, ID: protocol.ChannelResponseOrEvent, Data: []byte{0x01}}
+	truncated.SetChecksum()
+	stick.send(truncated)
+	if derr := nextDaemonError(t, antbuf); derr.Kind != ErrorProtocol || !errors.Is(derr, protocol.ErrDataLength) {
+		t.Fatal("Unexpected error, ", derr)
+	}
+	if pkt, err := antbuf.Wait(); err != ErrAntTimedout {
+		t.Fatal("Packet of the wrong length was passed on, ", pkt, err)
+	}
+
+	// Losing the stick stops the Antbuffer
+	flaky.readErrs <- fmt.Errorf("%w: unplugged", ErrDeviceGone)
+	stick.send(broadcast)
+	if derr := nextDaemonError(t, antbuf); derr.Kind != ErrorDeviceGone {
+		t.Fatal("Unexpected error, ", derr)
+	}
+	select {
+	case <-antbuf.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Antbuffer did not stop when the stick was lost")
+	}
+	if !errors.Is(antbuf.Err(), ErrDeviceGone) {
+		t.Fatal("Err does not report the lost stick, ", antbuf.Err())
+	}
+	if _, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01); err != ErrAntbufferClosed {
+		t.Fatal("Expected ErrAntbufferClosed after losing the stick, got ", err)
+	}
+	antbuf.Close()
+}
+
+func TestCapabilities(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	c := antbuf.Capabilities()
+	if c.MaxChannels != 8 || c.MaxNetworks != 3 {
+		t.Fatal("Unexpected limits, ", c)
+	}
+	if !c.CanReceive() || !c.BurstMessages() || !c.ExtendedMessages() || !c.ExtendedAssign() || !c.SerialNumber() {
+		t.Fatal("Unexpected options, ", c)
+	}
+	if c.Advanced3 != 0 || c.Advanced4 != 0 {
+		t.Fatal("Options missing from the reply were not zero, ", c)
+	}
+
+	// Channels beyond the stick's limit are rejected without talking to it
+	stick.ids()
+	if _, err = antbuf.SetupChannel(8, devicetype.Heartrate); err != ErrChannelOutOfRange {
+		t.Fatal("Expected ErrChannelOutOfRange, got ", err)
+	}
+	if _, err = antbuf.RegisterHandler(8, AnyClass, make(chan *protocol.Antpacket)); err != ErrChannelOutOfRange {
+		t.Fatal("Expected ErrChannelOutOfRange registering a handler, got ", err)
+	}
+	if ids := stick.ids(); len(ids) != 0 {
+		t.Fatalf("Out of range channel was sent to the stick: % X", ids)
+	}
+}
+
+func TestCapabilitiesOldFirmware(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.Capabilities {
+			reply, _ := protocol.GenerateAntpacket(protocol.Capabilities, 4, 1, 0x00, 0x02)
+			return []*protocol.Antpacket{reply}
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, nil)
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c := antbuf.Capabilities()
+	if c.MaxChannels != 4 || c.MaxNetworks != 1 || c.Advanced != NetworkEnabled {
+		t.Fatal("Unexpected capabilities, ", c)
+	}
+	if c.Advanced2 != 0 || c.ExtendedMessages() {
+		t.Fatal("Options missing from the reply were not zero, ", c)
+	}
+}
+
+// usbLoopback pretends to be a USB transport.
+type usbLoopback struct {
+	Transport
+}
+
+func (usbLoopback) USBIDs() (vendor, product uint16) {
+	return 0x0fcf, 0x1008
+}
+
+func TestDeviceInfo(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(usbLoopback{transport}, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	info, err := antbuf.DeviceInfo()
+	if err != nil {
+		t.Fatal("Error getting device info, ", err)
+	}
+	if info.Version != "AJK1.04RAF" || info.SerialNumber != 0x12345678 {
+		t.Fatalf("Unexpected identity %q %X", info.Version, info.SerialNumber)
+	}
+	if info.VendorID != 0x0fcf || info.ProductID != 0x1008 {
+		t.Fatalf("Unexpected USB ids %04X:%04X", info.VendorID, info.ProductID)
+	}
+	if info.Capabilities.MaxChannels != 8 {
+		t.Fatal("Capabilities missing from device info")
+	}
+}
+
+func TestDoneWhenWriteFindsStickGone(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+	vanishing := &vanishingTransport{Transport: transport}
+
+	antbuf, err := NewAntbuffer(vanishing, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	defer antbuf.Close()
+
+	// Let the read already under way finish, after which reads time out
+	atomic.StoreInt32(&vanishing.gone, 1)
+	broadcast, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	stick.send(broadcast)
+
+	pkt, _ := protocol.GenerateAntpacket(protocol.OpenChannel, 0x01)
+	if err = antbuf.Send(pkt); !errors.Is(err, ErrDeviceGone) {
+		t.Fatal("Expected ErrDeviceGone from Send, got ", err)
+	}
+	select {
+	case <-antbuf.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Antbuffer did not stop while reads time out")
+	}
+}
+
+func TestDoneWhenTransportClosesUnderneath(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	defer antbuf.Close()
+
+	stick.transport.Close()
+	select {
+	case <-antbuf.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Antbuffer did not stop when its transport closed")
+	}
+	if !errors.Is(antbuf.Err(), ErrDeviceGone) {
+		t.Fatal("Expected ErrDeviceGone from Err, got ", antbuf.Err())
+	}
+	if derr := nextDaemonError(t, antbuf); derr.Kind != ErrorDeviceGone || derr.Op != "read" {
+		t.Fatal("Expected a device gone read error, got ", derr)
+	}
+}
+
+func TestSerialErrorRejectsOnlyEchoed(t *testing.T) {
+	// Each round, the stick holds back its reply to an AssignChannel until
+	// the following OpenChannel, which it reports garbled, echoed or not
+	rounds := make(chan bool, 1)
+	assigning := make(chan bool, 1)
+	var held *protocol.Antpacket
+	var echo bool
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		switch {
+		case cmd.ID == protocol.AssignChannel && held == nil:
+			select {
+			case echo = <-rounds:
+				held = cmd
+				assigning <- true
+				return nil
+			default:
+			}
+		case cmd.ID == protocol.OpenChannel && held != nil:
+			args := []byte{byte(protocol.SerialErrorChecksum)}
+			if echo {
+				line := new(bytes.Buffer)
+				cmd.ToBinary(line)
+				args = append(args, line.Bytes()...)
+			}
+			serr, _ := protocol.GenerateAntpacket(protocol.SerialErrorMessage, args...)
+			replies := append([]*protocol.Antpacket{serr}, defaultReplies(held)...)
+			held = nil
+			return replies
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	for _, echoed := range []bool{true, false} {
+		stick.ids()
+		rounds <- echoed
+		assigned := make(chan error)
+		go func() {
+			_, err := antbuf.GenSendAndWait(protocol.AssignChannel, 0x02, 0x00, 0x01)
+			assigned <- err
+		}()
+		<-assigning
+
+		if _, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01); err != nil {
+			t.Fatal("Command was not retried, ", err)
+		}
+		if err = <-assigned; err != nil {
+			t.Fatal("Error assigning channel, ", err)
+		}
+
+		// Only the echoed command is resent; without an echo, both are
+		assigns, opens := 0, 0
+		for _, id := range stick.ids() {
+			switch id {
+			case protocol.AssignChannel:
+				assigns++
+			case protocol.OpenChannel:
+				opens++
+			}
+		}
+		if opens != 2 || (echoed && assigns != 1) || (!echoed && assigns != 2) {
+			t.Fatalf("Echoed %v: AssignChannel sent %d times, OpenChannel %d times", echoed, assigns, opens)
+		}
+	}
+}
+
+func TestSerialErrorRetry(t *testing.T) {
+	garbled := make(chan bool, 10)
+	garbled <- true
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.OpenChannel {
+			select {
+			case <-garbled:
+				// The stick echoes back what it received
+				echo := new(bytes.Buffer)
+				cmd.ToBinary(echo)
+				serr, _ := protocol.GenerateAntpacket(protocol.SerialErrorMessage, append([]byte{byte(protocol.SerialErrorChecksum)}, echo.Bytes()...)...)
+				return []*protocol.Antpacket{serr}
+			default:
+			}
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8), WithSerialRetries(1))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	stick.ids()
+
+	// The stick misreads the command once, so it is resent
+	if _, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01); err != nil {
+		t.Fatal("Command was not retried, ", err)
+	}
+	if got := stick.ids(); !bytes.Equal(got, []byte{protocol.OpenChannel, protocol.OpenChannel}) {
+		t.Fatalf("Unexpected packets sent % X", got)
+	}
+	derr := nextDaemonError(t, antbuf)
+	var serr *protocol.SerialError
+	if derr.Kind != ErrorProtocol || !errors.As(derr, &serr) || serr.Code != protocol.SerialErrorChecksum {
+		t.Fatal("Unexpected error, ", derr)
+	}
+	if len(serr.Message) != 5 || serr.Message[2] != protocol.OpenChannel {
+		t.Fatalf("Unexpected echo % X", serr.Message)
+	}
+
+	// Retries run out
+	garbled <- true
+	garbled <- true
+	_, err = antbuf.GenSendAndWait(protocol.OpenChannel, 0x01)
+	if !errors.As(err, &serr) {
+		t.Fatal("Expected SerialError once retries ran out, got ", err)
+	}
+}
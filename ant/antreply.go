@@ -0,0 +1,161 @@
+package ant
+
+import (
+	"fmt"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// A replyWaiter is an outstanding request for the reply to a sent packet.
+// If the stick reports it could not read what was sent, the error arrives on rejected.
+type replyWaiter struct {
+	sent     *protocol.Antpacket
+	match    func(*protocol.Antpacket) bool
+	reply    chan *protocol.Antpacket
+	rejected chan error
+}
+
+// replyMatcher returns a function recognising the stick's reply to sent.
+//
+// SystemReset is answered by a StartupMessage, RequestMessage by the requested
+// message (or a response carrying an error code) and every other command by a
+// ChannelResponseOrEvent naming the command's message id and channel.
+func replyMatcher(sent *protocol.Antpacket) func(*protocol.Antpacket) bool {
+	switch sent.ID {
+	case protocol.SystemReset:
+		return func(pkt *protocol.Antpacket) bool {
+			return pkt.ID == protocol.StartupMessage
+		}
+	case protocol.RequestMessage:
+		if len(sent.Data) < 2 {
+			// Not a request the stick can answer
+			return func(*protocol.Antpacket) bool { return false }
+		}
+		channel, requested := sent.Data[0], sent.Data[1]
+		return func(pkt *protocol.Antpacket) bool {
+			if pkt.ID == protocol.ChannelResponseOrEvent {
+				resp, err := protocol.DecodeChannelResponse(pkt)
+				return err == nil && resp.Channel == channel && resp.MessageID == protocol.RequestMessage
+			}
+			if pkt.ID != requested {
+				return false
+			}
+			if c, ok := protocol.MsgClasses[pkt.ID]; ok && c.HasChannel() {
+				return len(pkt.Data) > 0 && pkt.Data[0] == channel
+			}
+			return true
+		}
+	default:
+		c, ok := protocol.MsgClasses[sent.ID]
+		hasChannel := ok && c.HasChannel() && len(sent.Data) > 0
+		return func(pkt *protocol.Antpacket) bool {
+			if pkt.ID != protocol.ChannelResponseOrEvent {
+				return false
+			}
+			resp, err := protocol.DecodeChannelResponse(pkt)
+			if err != nil || resp.MessageID != sent.ID {
+				return false
+			}
+			return !hasChannel || resp.Channel == sent.Data[0]
+		}
+	}
+}
+
+// expectReply registers a waiter for the reply to sent.
+// It must be registered before sending so that a fast reply is not missed.
+func (a *Antbuffer) expectReply(sent *protocol.Antpacket) *replyWaiter {
+	w := &replyWaiter{sent, replyMatcher(sent), make(chan *protocol.Antpacket, 1), make(chan error, 1)}
+
+	a.pendingLock.Lock()
+	a.pending = append(a.pending, w)
+	a.pendingLock.Unlock()
+
+	return w
+}
+
+// cancelReply removes a waiter which is no longer interested in its reply.
+func (a *Antbuffer) cancelReply(w *replyWaiter) {
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+
+	for i, x := range a.pending {
+		if x == w {
+			a.pending = append(a.pending[:i], a.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// deliverReply hands pkt to the oldest waiter it answers.
+// Returns false if pkt is not a reply anybody is waiting for.
+func (a *Antbuffer) deliverReply(pkt *protocol.Antpacket) bool {
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+
+	for i, w := range a.pending {
+		if w.match(pkt) {
+			a.pending = append(a.pending[:i], a.pending[i+1:]...)
+			w.reply <- pkt
+			return true
+		}
+	}
+	return false
+}
+
+// rejectReplies fails the oldest waiter for the message the stick echoed back
+// in serr. Without an echo to go by, every waiter is failed, as any of their
+// messages may have been the one lost.
+func (a *Antbuffer) rejectReplies(serr *protocol.SerialError) {
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+
+	id, data, ok := serr.Echoed()
+	if !ok {
+		for _, w := range a.pending {
+			w.rejected <- serr
+		}
+		a.pending = nil
+		return
+	}
+
+	for i, w := range a.pending {
+		if w.sent.ID != id {
+			continue
+		}
+		c, known := protocol.MsgClasses[id]
+		if known && c.HasChannel() && len(data) > 0 && len(w.sent.Data) > 0 && w.sent.Data[0] != data[0] {
+			continue
+		}
+		a.pending = append(a.pending[:i], a.pending[i+1:]...)
+		w.rejected <- serr
+		return
+	}
+}
+
+// A ResponseError is an error code returned by the stick in response to a command.
+// It unwraps to its ResponseCode, so errors.Is(err, protocol.ChannelInWrongState) works.
+type ResponseError struct {
+	Channel   byte
+	MessageID byte
+	Code      protocol.ResponseCode
+}
+
+func (r *ResponseError) Error() string {
+	name := fmt.Sprintf("0x%02X", r.MessageID)
+	if c, ok := protocol.MsgClasses[r.MessageID]; ok {
+		name = c.Name
+	}
+	return fmt.Sprintf("\"%s\" on channel %d failed with %v", name, r.Channel, r.Code)
+}
+
+func (r *ResponseError) Unwrap() error {
+	return r.Code
+}
+
+// responseError returns the error carried by a ChannelResponseOrEvent reply, if any.
+func responseError(pkt *protocol.Antpacket) error {
+	resp, err := protocol.DecodeChannelResponse(pkt)
+	if err != nil || !resp.Code.IsError() {
+		return nil
+	}
+	return &ResponseError{resp.Channel, resp.MessageID, resp.Code}
+}
@@ -0,0 +1,88 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// SendBurst sends data as a burst transfer and waits for the stick to report
+// how it went. The data is split into 8 byte packets, the last zero padded.
+// Returns ErrTransferFailed if the burst did not get through.
+func (c *Channel) SendBurst(data []byte) error {
+	return c.SendBurstContext(context.Background(), data)
+}
+
+// SendBurstContext is SendBurst bounded by ctx.
+func (c *Channel) SendBurstContext(ctx context.Context, data []byte) error {
+	if len(data) == 0 {
+		return ErrEmptyBurst
+	}
+	if !c.antbuf.capabilities.BurstMessages() {
+		return ErrNotSupported
+	}
+
+	// Bursts on a channel must not interleave, nor overlap acknowledged data
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	if c.State() != ChannelOpen {
+		return ErrChannelState
+	}
+
+	var pkts []*protocol.Antpacket
+	for _, m := range message.SplitBurst(c.number, data) {
+		pkt, err := m.Marshal()
+		if err != nil {
+			return err
+		}
+		pkts = append(pkts, pkt)
+	}
+	return c.awaitTransfer(ctx, pkts...)
+}
+
+// burstAssembler reassembles the bursts received on a channel. It is only
+// used by deliver, on the read daemon.
+type burstAssembler struct {
+	data   []byte
+	active bool
+	// next is the sequence number expected of the next packet
+	next byte
+}
+
+// add adds a burst packet, returning the data of the whole burst once its
+// last packet arrives. A packet out of sequence abandons the burst.
+func (b *burstAssembler) add(pkt *protocol.Antpacket) ([]byte, error) {
+	seq := pkt.Data[0] >> 5
+	count := seq &^ message.BurstLast
+	if count == 0 {
+		// A new burst, dropping any which never finished
+		b.reset()
+	} else if !b.active || count != b.next {
+		b.reset()
+		return nil, ErrBurstSequence
+	}
+
+	b.active = true
+	b.data = append(b.data, pkt.Data[1:9]...)
+	b.next = count%3 + 1
+	if seq&message.BurstLast == 0 {
+		return nil, nil
+	}
+	data := b.data
+	b.reset()
+	return data, nil
+}
+
+func (b *burstAssembler) reset() {
+	b.data, b.active = nil, false
+}
+
+// receiveBurst feeds the burst packets among the channel's events to its assembler.
+func (c *Channel) receiveBurst(ev *ChannelEvent) {
+	switch {
+	case ev.ID == protocol.BurstTransferData && ev.Payload != nil:
+		ev.Burst, ev.BurstErr = c.burst.add(ev.Packet)
+	case ev.Response != nil && ev.Response.IsEvent() && ev.Response.Code == protocol.EventTransferRxFailed:
+		c.burst.reset()
+	}
+}
@@ -0,0 +1,126 @@
+package ant
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+)
+
+// burstReplies reports the outcome of a burst after its last packet, failing
+// bursts whose first byte is 0xFF, on top of defaultReplies.
+func burstReplies(cmd *protocol.Antpacket) []*protocol.Antpacket {
+	if cmd.ID != protocol.BurstTransferData {
+		return defaultReplies(cmd)
+	}
+	seq := cmd.Data[0] >> 5
+	channel := cmd.Data[0] & 0x1F
+	event := func(code protocol.ResponseCode) *protocol.Antpacket {
+		pkt, _ := protocol.GenerateAntpacket(protocol.ChannelResponseOrEvent, channel, 0x01, byte(code))
+		return pkt
+	}
+
+	var replies []*protocol.Antpacket
+	if seq&^message.BurstLast == 0 {
+		replies = append(replies, event(protocol.EventTransferTxStart))
+		if cmd.Data[1] == 0xFF {
+			return append(replies, event(protocol.EventTransferTxFailed))
+		}
+	}
+	if seq&message.BurstLast != 0 {
+		replies = append(replies, event(protocol.EventTransferTxCompleted))
+	}
+	return replies
+}
+
+func TestSendBurst(t *testing.T) {
+	stick, transport := newFakeStick(t, burstReplies)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+	if err = c.SendBurst(nil); err != ErrEmptyBurst {
+		t.Fatal("Expected ErrEmptyBurst, got ", err)
+	}
+
+	stick.ids()
+	data := make([]byte, 35)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if err = c.SendBurst(data); err != nil {
+		t.Fatal("Error sending burst, ", err)
+	}
+
+	// Sequence numbers count 0, 1, 2, 3, 1 and the last is flagged
+	var sent []byte
+	for _, seq := range []byte{0x00, 0x01, 0x02, 0x03, 0x05} {
+		pkt := <-stick.received
+		if pkt.ID != protocol.BurstTransferData || pkt.Data[0] != seq<<5|0x01 {
+			t.Fatal("Unexpected burst packet, ", pkt)
+		}
+		sent = append(sent, pkt.Data[1:9]...)
+	}
+	if !bytes.Equal(sent[:35], data) || !bytes.Equal(sent[35:], []byte{0, 0, 0, 0, 0}) {
+		t.Fatalf("Burst sent % X", sent)
+	}
+
+	data[0] = 0xFF
+	if err = c.SendBurst(data); err != ErrTransferFailed {
+		t.Fatal("Expected ErrTransferFailed, got ", err)
+	}
+}
+
+func TestReceiveBurst(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	send := func(seq byte, first byte) {
+		pkt, _ := (&message.BurstTransferData{Channel: 0x01, Sequence: seq, Data: [8]byte{first}}).Marshal()
+		stick.send(pkt)
+	}
+
+	for i, seq := range []byte{0, 1, 2, 3, 1 | message.BurstLast} {
+		send(seq, byte(i))
+	}
+	var ev ChannelEvent
+	for i := 0; i < 5; i++ {
+		ev = <-c.Events()
+		if ev.BurstErr != nil || (i < 4 && ev.Burst != nil) {
+			t.Fatal("Unexpected burst event, ", ev.Burst, ev.BurstErr)
+		}
+	}
+	if len(ev.Burst) != 40 || ev.Burst[0] != 0 || ev.Burst[8] != 1 || ev.Burst[32] != 4 {
+		t.Fatalf("Reassembled burst % X", ev.Burst)
+	}
+
+	// A skipped packet abandons the burst
+	send(0, 0)
+	send(2|message.BurstLast, 2)
+	<-c.Events()
+	if ev = <-c.Events(); ev.BurstErr != ErrBurstSequence || ev.Burst != nil {
+		t.Fatal("Expected ErrBurstSequence, got ", ev.BurstErr)
+	}
+
+	// A single packet burst
+	send(message.BurstLast, 9)
+	if ev = <-c.Events(); len(ev.Burst) != 8 || ev.Burst[0] != 9 {
+		t.Fatalf("Reassembled burst % X", ev.Burst)
+	}
+}
@@ -0,0 +1,145 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// StandardOptions is the standard options bitfield of the Capabilities message.
+// Its flags mark features the stick does NOT support.
+type StandardOptions byte
+
+const (
+	NoReceiveChannels  StandardOptions = 0x01
+	NoTransmitChannels StandardOptions = 0x02
+	NoReceiveMessages  StandardOptions = 0x04
+	NoTransmitMessages StandardOptions = 0x08
+	NoAckdMessages     StandardOptions = 0x10
+	NoBurstMessages    StandardOptions = 0x20
+)
+
+// AdvancedOptions is the first advanced options bitfield of the Capabilities message.
+type AdvancedOptions byte
+
+const (
+	NetworkEnabled           AdvancedOptions = 0x02
+	SerialNumberEnabled      AdvancedOptions = 0x08
+	PerChannelTxPowerEnabled AdvancedOptions = 0x10
+	LowPrioritySearchEnabled AdvancedOptions = 0x20
+	ScriptEnabled            AdvancedOptions = 0x40
+	SearchListEnabled        AdvancedOptions = 0x80
+)
+
+// AdvancedOptions2 is the second advanced options bitfield of the Capabilities message.
+type AdvancedOptions2 byte
+
+const (
+	LEDEnabled        AdvancedOptions2 = 0x01
+	ExtMessageEnabled AdvancedOptions2 = 0x02
+	ScanModeEnabled   AdvancedOptions2 = 0x04
+	ProxSearchEnabled AdvancedOptions2 = 0x10
+	ExtAssignEnabled  AdvancedOptions2 = 0x20
+	FSAntFSEnabled    AdvancedOptions2 = 0x40
+)
+
+// AdvancedOptions3 is the third advanced options bitfield, sent by newer firmware only.
+type AdvancedOptions3 byte
+
+const (
+	AdvancedBurstEnabled        AdvancedOptions3 = 0x01
+	EventBufferingEnabled       AdvancedOptions3 = 0x02
+	EventFilteringEnabled       AdvancedOptions3 = 0x04
+	HighDutySearchEnabled       AdvancedOptions3 = 0x08
+	SearchSharingEnabled        AdvancedOptions3 = 0x10
+	SelectiveDataUpdatesEnabled AdvancedOptions3 = 0x40
+	EncryptedChannelEnabled     AdvancedOptions3 = 0x80
+)
+
+// AdvancedOptions4 is the fourth advanced options bitfield, sent by newer firmware only.
+type AdvancedOptions4 byte
+
+const (
+	RFActiveNotificationEnabled AdvancedOptions4 = 0x01
+)
+
+// Capabilities describes what an ant stick supports, as reported by the Capabilities message.
+// Option fields the stick's firmware did not send are zero.
+type Capabilities struct {
+	MaxChannels          byte
+	MaxNetworks          byte
+	Standard             StandardOptions
+	Advanced             AdvancedOptions
+	Advanced2            AdvancedOptions2
+	MaxSensRcoreChannels byte
+	Advanced3            AdvancedOptions3
+	Advanced4            AdvancedOptions4
+}
+
+// newCapabilities converts the Capabilities message. Options the stick's
+// firmware did not send are zero.
+func newCapabilities(m *message.Capabilities) Capabilities {
+	return Capabilities{
+		MaxChannels:          m.MaxChannels,
+		MaxNetworks:          m.MaxNetworks,
+		Standard:             StandardOptions(m.StandardOptions),
+		Advanced:             AdvancedOptions(m.AdvancedOptions),
+		Advanced2:            AdvancedOptions2(m.AdvancedOptions2),
+		MaxSensRcoreChannels: m.MaxSensRcoreChannels,
+		Advanced3:            AdvancedOptions3(m.AdvancedOptions3),
+		Advanced4:            AdvancedOptions4(m.AdvancedOptions4),
+	}
+}
+
+// CanReceive reports whether the stick supports receive channels.
+func (c Capabilities) CanReceive() bool {
+	return c.Standard&NoReceiveChannels == 0
+}
+
+// CanTransmit reports whether the stick supports transmit channels.
+func (c Capabilities) CanTransmit() bool {
+	return c.Standard&NoTransmitChannels == 0
+}
+
+// AcknowledgedMessages reports whether the stick supports acknowledged data.
+func (c Capabilities) AcknowledgedMessages() bool {
+	return c.Standard&NoAckdMessages == 0
+}
+
+// BurstMessages reports whether the stick supports burst transfers.
+func (c Capabilities) BurstMessages() bool {
+	return c.Standard&NoBurstMessages == 0
+}
+
+// ExtendedMessages reports whether the stick can append extended data to received messages.
+func (c Capabilities) ExtendedMessages() bool {
+	return c.Advanced2&ExtMessageEnabled != 0
+}
+
+// ExtendedAssign reports whether the stick accepts the extended assignment byte of AssignChannel.
+func (c Capabilities) ExtendedAssign() bool {
+	return c.Advanced2&ExtAssignEnabled != 0
+}
+
+// SerialNumber reports whether the stick has a serial number.
+func (c Capabilities) SerialNumber() bool {
+	return c.Advanced&SerialNumberEnabled != 0
+}
+
+// Capabilities returns what the stick reported it supports when the Antbuffer was created.
+func (a *Antbuffer) Capabilities() Capabilities {
+	return a.capabilities
+}
+
+// requestCapabilities asks the stick for its capabilities.
+func (a *Antbuffer) requestCapabilities(ctx context.Context) (Capabilities, error) {
+	reply, err := a.SendAndWaitContext(ctx, &message.RequestMessage{MessageID: protocol.Capabilities})
+	if err != nil {
+		return Capabilities{}, err
+	}
+	var c message.Capabilities
+	if err = c.Unmarshal(reply); err != nil {
+		return Capabilities{}, err
+	}
+	return newCapabilities(&c), nil
+}
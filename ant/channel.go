@@ -0,0 +1,546 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"log"
+	"sync"
+	"time"
+)
+
+// ChannelState is the lifecycle of a Channel as tracked by the host.
+type ChannelState int
+
+const (
+	ChannelUnassigned ChannelState = iota
+	ChannelAssigned
+	ChannelOpen
+)
+
+func (s ChannelState) String() string {
+	switch s {
+	case ChannelUnassigned:
+		return "unassigned"
+	case ChannelAssigned:
+		return "assigned"
+	case ChannelOpen:
+		return "open"
+	}
+	return fmt.Sprintf("ChannelState(%d)", int(s))
+}
+
+// A ChannelEvent is a packet received on a channel.
+type ChannelEvent struct {
+	// ID is the message id of the packet
+	ID byte
+	// Payload holds the 8 data bytes of broadcast, acknowledged and burst data
+	Payload []byte
+	// Response is set for ChannelResponseOrEvent packets
+	Response *protocol.ChannelResponse
+	// DeviceID, RSSI and Timestamp are set for data the stick sent extended
+	// with them; see EnableExtendedMessages. Timestamp is the time of receipt in
+	// 1/32768 s, rolling over every 2 s.
+	DeviceID  *ChannelID
+	RSSI      *RSSI
+	Timestamp *uint16
+	// Burst is set on the last packet of a burst to the data of the whole
+	// burst. BurstErr is set instead on a packet out of sequence, which
+	// abandons the burst.
+	Burst    []byte
+	BurstErr error
+	Packet   *protocol.Antpacket
+}
+
+func newChannelEvent(pkt *protocol.Antpacket) ChannelEvent {
+	ev := ChannelEvent{ID: pkt.ID, Packet: pkt}
+	switch pkt.ID {
+	case protocol.BroadcastData, protocol.AcknowledgeData, protocol.BurstTransferData:
+		if len(pkt.Data) >= 9 {
+			ev.Payload = pkt.Data[1:9]
+			ev.setExtended(pkt.Data[9:])
+		}
+	case protocol.ChannelResponseOrEvent:
+		ev.Response, _ = protocol.DecodeChannelResponse(pkt)
+	}
+	return ev
+}
+
+// A Channel is one of the stick's channels, assigned to a device type.
+//
+// The Channel tracks its own state so that misuse, such as opening it twice,
+// is rejected without bothering the stick.
+type Channel struct {
+	antbuf *Antbuffer
+	number byte
+	dev    *devicetype.Antdevicetype
+	// state is guarded by the Antbuffer's channelsLock
+	state ChannelState
+	// opLock serialises operations on the channel
+	opLock     sync.Mutex
+	events     chan ChannelEvent
+	burst      burstAssembler
+	unregister func()
+}
+
+// RadioState is the state of a channel as reported by the stick in ChannelStatus.
+type RadioState byte
+
+const (
+	RadioUnassigned RadioState = 0
+	RadioAssigned   RadioState = 1
+	RadioSearching  RadioState = 2
+	RadioTracking   RadioState = 3
+)
+
+func (s RadioState) String() string {
+	switch s {
+	case RadioUnassigned:
+		return "unassigned"
+	case RadioAssigned:
+		return "assigned"
+	case RadioSearching:
+		return "searching"
+	case RadioTracking:
+		return "tracking"
+	}
+	return fmt.Sprintf("RadioState(%d)", byte(s))
+}
+
+// ChannelStatus is a decoded ChannelStatus message.
+type ChannelStatus struct {
+	State       RadioState
+	Network     byte
+	ChannelType byte
+}
+
+// ChannelID is a decoded ChannelID message.
+type ChannelID struct {
+	DeviceNumber     uint16
+	DeviceType       byte
+	TransmissionType byte
+}
+
+// Pairing reports whether the pairing bit of the device type is set.
+func (id ChannelID) Pairing() bool {
+	return id.DeviceType&0x80 != 0
+}
+
+// AssignChannel assigns channel number to the device type dev and configures it.
+// The returned Channel is assigned but not yet open.
+func (a *Antbuffer) AssignChannel(number byte, dev *devicetype.Antdevicetype) (*Channel, error) {
+	return a.AssignChannelContext(context.Background(), number, dev)
+}
+
+// AssignChannelContext is AssignChannel with the configuration sequence bounded by ctx.
+func (a *Antbuffer) AssignChannelContext(ctx context.Context, number byte, dev *devicetype.Antdevicetype) (*Channel, error) {
+	if number >= a.capabilities.MaxChannels {
+		return nil, ErrChannelOutOfRange
+	}
+
+	// Claim the number
+	a.channelsLock.Lock()
+	if _, ok := a.channels[number]; ok {
+		a.channelsLock.Unlock()
+		return nil, ErrChannelInUse
+	}
+	c := a.newChannel(number, dev)
+	a.channelsLock.Unlock()
+
+	return a.assign(ctx, c)
+}
+
+// newChannel records a Channel holding number. The Channel is returned with
+// its opLock held. The channelsLock must be held.
+func (a *Antbuffer) newChannel(number byte, dev *devicetype.Antdevicetype) *Channel {
+	c := &Channel{
+		antbuf: a,
+		number: number,
+		dev:    dev,
+		events: make(chan ChannelEvent, 20),
+	}
+	c.opLock.Lock()
+	a.channels[number] = c
+	return c
+}
+
+// assign sets up a Channel from newChannel on the stick, releasing it on failure.
+func (a *Antbuffer) assign(ctx context.Context, c *Channel) (*Channel, error) {
+	defer c.opLock.Unlock()
+
+	// Listen before anything can arrive
+	unregister, err := a.registerHandler(int(c.number), AnyClass, c.deliver)
+	if err != nil {
+		a.releaseChannel(c)
+		return nil, err
+	}
+	c.unregister = unregister
+
+	err = c.configure(ctx)
+	if err != nil {
+		if c.State() == ChannelAssigned {
+			// Free the stick's channel even if ctx is what failed
+			a.GenSendAndWait(protocol.UnassignChannel, c.number)
+		}
+		c.release()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// configure assigns the channel and sends the device type's properties.
+func (c *Channel) configure(ctx context.Context) error {
+	a, channel, dev := c.antbuf, c.number, c.dev
+
+	network, ok := a.Network(dev.Network)
+	if !ok {
+		return ErrUnknownNetwork
+	}
+
+	// Setup Channel Type (Assign Channel)
+	_, err := a.SendAndWaitContext(ctx, &message.AssignChannel{Channel: channel, ChannelType: dev.ChannelType, Network: network})
+	if err != nil {
+		return err
+	}
+	c.setState(ChannelAssigned)
+
+	// The properties of the device type
+	// TODO: Allow for pairing bit or not on DeviceType
+	config := []message.Message{
+		&message.SetChannelRFFrequency{Channel: channel, Frequency: dev.RFChannelFreq},
+		&message.SetChannelID{Channel: channel, DeviceNumber: dev.DeviceNumber, DeviceType: dev.DeviceType, TransmissionType: dev.TransmissionType},
+		&message.SetChannelPeriod{Channel: channel, Period: dev.ChannelPeriod},
+		&message.SetSearchTimeout{Channel: channel, Timeout: dev.SearchTimeout},
+	}
+	for _, msg := range config {
+		_, err = a.SendAndWaitContext(ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Number returns the channel number on the stick.
+func (c *Channel) Number() byte {
+	return c.number
+}
+
+// DeviceType returns the device type the channel was assigned to.
+func (c *Channel) DeviceType() *devicetype.Antdevicetype {
+	return c.dev
+}
+
+// State returns the state of the channel.
+func (c *Channel) State() ChannelState {
+	c.antbuf.channelsLock.Lock()
+	defer c.antbuf.channelsLock.Unlock()
+	return c.state
+}
+
+func (c *Channel) setState(state ChannelState) {
+	c.antbuf.channelsLock.Lock()
+	defer c.antbuf.channelsLock.Unlock()
+	c.state = state
+}
+
+// Events returns the stream of packets received on the channel.
+// It is closed when the channel is unassigned.
+func (c *Channel) Events() <-chan ChannelEvent {
+	return c.events
+}
+
+// deliver hands a packet to the event stream without blocking the Antbuffer.
+func (c *Channel) deliver(pkt *protocol.Antpacket) {
+	ev := newChannelEvent(pkt)
+	c.receiveBurst(&ev)
+	select {
+	case c.events <- ev:
+	default:
+		log.Println("Channel ", c.number, " full, dropped packet: ", pkt.Describe(protocol.ANTToHost))
+	}
+}
+
+// Open opens the channel to begin searching for its device.
+func (c *Channel) Open() error {
+	return c.OpenContext(context.Background())
+}
+
+// OpenContext is Open bounded by ctx.
+func (c *Channel) OpenContext(ctx context.Context) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	if c.State() != ChannelAssigned {
+		return ErrChannelState
+	}
+
+	_, err := c.antbuf.GenSendAndWaitContext(ctx, protocol.OpenChannel, c.number)
+	if err != nil {
+		return err
+	}
+	c.setState(ChannelOpen)
+	return nil
+}
+
+// Close closes the channel and waits for the stick to report EVENT_CHANNEL_CLOSED.
+func (c *Channel) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext is Close bounded by ctx.
+func (c *Channel) CloseContext(ctx context.Context) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	if c.State() != ChannelOpen {
+		return ErrChannelState
+	}
+
+	a := c.antbuf
+
+	// Listen for the event before it can arrive
+	events := make(chan *protocol.Antpacket, 20)
+	unregister, err := a.RegisterHandler(int(c.number), int(protocol.ChannelResponseOrEvent), events)
+	if err != nil {
+		return err
+	}
+	defer unregister()
+
+	_, err = a.GenSendAndWaitContext(ctx, protocol.CloseChannel, c.number)
+	if errors.Is(err, protocol.ChannelInWrongState) {
+		// Already closed by the stick
+		c.setState(ChannelAssigned)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Wait for complete close
+	timeout := time.NewTimer(a.closeTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case pkt := <-events:
+			resp, err := protocol.DecodeChannelResponse(pkt)
+			if err == nil && resp.IsEvent() && resp.Code == protocol.EventChannelClosed {
+				c.setState(ChannelAssigned)
+				return nil
+			}
+		case <-timeout.C:
+			return ErrAntTimedout
+		case <-a.quit:
+			return ErrAntbufferClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Unassign releases a closed channel on the stick. The event stream is closed
+// and the Channel may not be used again.
+func (c *Channel) Unassign() error {
+	return c.UnassignContext(context.Background())
+}
+
+// UnassignContext is Unassign bounded by ctx.
+func (c *Channel) UnassignContext(ctx context.Context) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	if c.State() != ChannelAssigned {
+		return ErrChannelState
+	}
+
+	_, err := c.antbuf.GenSendAndWaitContext(ctx, protocol.UnassignChannel, c.number)
+	c.release()
+	return err
+}
+
+// release forgets the channel, stops its event stream and frees its number.
+func (c *Channel) release() {
+	c.setState(ChannelUnassigned)
+	c.unregister()
+	// No more deliveries once unregistered
+	close(c.events)
+	c.antbuf.releaseChannel(c)
+}
+
+// releaseChannel frees the channel number held by c.
+func (a *Antbuffer) releaseChannel(c *Channel) {
+	a.channelsLock.Lock()
+	defer a.channelsLock.Unlock()
+	if a.channels[c.number] == c {
+		delete(a.channels, c.number)
+	}
+}
+
+// Status requests the channel's status from the stick.
+func (c *Channel) Status() (ChannelStatus, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext is Status bounded by ctx.
+func (c *Channel) StatusContext(ctx context.Context) (ChannelStatus, error) {
+	if c.State() == ChannelUnassigned {
+		return ChannelStatus{}, ErrChannelState
+	}
+
+	reply, err := c.antbuf.SendAndWaitContext(ctx, &message.RequestMessage{Channel: c.number, MessageID: protocol.ChannelStatus})
+	if err != nil {
+		return ChannelStatus{}, err
+	}
+	var status message.ChannelStatus
+	if err = status.Unmarshal(reply); err != nil {
+		return ChannelStatus{}, err
+	}
+
+	return ChannelStatus{
+		State:       RadioState(status.State),
+		Network:     status.Network,
+		ChannelType: status.ChannelType,
+	}, nil
+}
+
+// ID requests the channel ID from the stick. Once a wildcard channel has paired,
+// this returns the id of the device found.
+func (c *Channel) ID() (ChannelID, error) {
+	return c.IDContext(context.Background())
+}
+
+// IDContext is ID bounded by ctx.
+func (c *Channel) IDContext(ctx context.Context) (ChannelID, error) {
+	if c.State() == ChannelUnassigned {
+		return ChannelID{}, ErrChannelState
+	}
+
+	reply, err := c.antbuf.SendAndWaitContext(ctx, &message.RequestMessage{Channel: c.number, MessageID: protocol.ChannelID})
+	if err != nil {
+		return ChannelID{}, err
+	}
+	var id message.ChannelID
+	if err = id.Unmarshal(reply); err != nil {
+		return ChannelID{}, err
+	}
+
+	return ChannelID{
+		DeviceNumber:     id.DeviceNumber,
+		DeviceType:       id.DeviceType,
+		TransmissionType: id.TransmissionType,
+	}, nil
+}
+
+// SendBroadcast queues 8 bytes of broadcast data, sent at the channel's next message period.
+func (c *Channel) SendBroadcast(payload []byte) error {
+	return c.SendBroadcastContext(context.Background(), payload)
+}
+
+// SendBroadcastContext is SendBroadcast bounded by ctx.
+func (c *Channel) SendBroadcastContext(ctx context.Context, payload []byte) error {
+	if len(payload) != 8 {
+		return ErrPayloadLength
+	}
+	if c.State() != ChannelOpen {
+		return ErrChannelState
+	}
+
+	pkt, err := protocol.GenerateAntpacket(protocol.BroadcastData, append([]byte{c.number}, payload...)...)
+	if err != nil {
+		return err
+	}
+	return c.antbuf.SendContext(ctx, pkt)
+}
+
+// SendAcknowledged sends 8 bytes of acknowledged data and waits for the other
+// end to acknowledge it. Returns ErrTransferFailed if it was not acknowledged.
+func (c *Channel) SendAcknowledged(payload []byte) error {
+	return c.SendAcknowledgedContext(context.Background(), payload)
+}
+
+// SendAcknowledgedContext is SendAcknowledged bounded by ctx.
+func (c *Channel) SendAcknowledgedContext(ctx context.Context, payload []byte) error {
+	if len(payload) != 8 {
+		return ErrPayloadLength
+	}
+
+	// The stick's report does not say which transfer it is for, so transfers
+	// on a channel must not overlap
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	if c.State() != ChannelOpen {
+		return ErrChannelState
+	}
+
+	pkt, err := protocol.GenerateAntpacket(protocol.AcknowledgeData, append([]byte{c.number}, payload...)...)
+	if err != nil {
+		return err
+	}
+	return c.awaitTransfer(ctx, pkt)
+}
+
+// awaitTransfer sends pkts and waits for the stick to report how the transfer
+// went. The rest of a burst is not sent once the stick gives up on it. The
+// opLock must be held, so that no other transfer takes the report.
+func (c *Channel) awaitTransfer(ctx context.Context, pkts ...*protocol.Antpacket) error {
+	a := c.antbuf
+
+	// Listen for the outcome before it can arrive
+	events := make(chan *protocol.Antpacket, 20)
+	unregister, err := a.RegisterHandler(int(c.number), int(protocol.ChannelResponseOrEvent), events)
+	if err != nil {
+		return err
+	}
+	defer unregister()
+
+	id := pkts[0].ID
+	for _, pkt := range pkts {
+		err = a.SendContext(ctx, pkt)
+		if err != nil {
+			return err
+		}
+		for len(events) > 0 {
+			if done, err := transferOutcome(<-events, id); done {
+				return err
+			}
+		}
+	}
+
+	timeout := time.NewTimer(a.transferTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case ev := <-events:
+			if done, err := transferOutcome(ev, id); done {
+				return err
+			}
+		case <-timeout.C:
+			return ErrAntTimedout
+		case <-a.quit:
+			return ErrAntbufferClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// transferOutcome reports whether ev ends a transfer of message id, and how.
+func transferOutcome(ev *protocol.Antpacket, id byte) (bool, error) {
+	resp, err := protocol.DecodeChannelResponse(ev)
+	if err != nil {
+		return false, nil
+	}
+	switch {
+	case resp.IsEvent() && resp.Code == protocol.EventTransferTxCompleted:
+		return true, nil
+	case resp.IsEvent() && resp.Code == protocol.EventTransferTxFailed:
+		return true, ErrTransferFailed
+	case resp.MessageID == id && resp.Code != protocol.ResponseNoError:
+		// Refused by the stick
+		return true, &ResponseError{resp.Channel, resp.MessageID, resp.Code}
+	}
+	return false, nil
+}
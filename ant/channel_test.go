@@ -0,0 +1,250 @@
+package ant
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+	"time"
+)
+
+// channelReplies answers the channel requests and acknowledged data on top of defaultReplies.
+// Acknowledged data whose first byte is 0xFF is not acknowledged by the other end.
+func channelReplies(cmd *protocol.Antpacket) []*protocol.Antpacket {
+	if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.ChannelStatus {
+		// Searching on network 1 as a slave
+		reply, _ := protocol.GenerateAntpacket(protocol.ChannelStatus, cmd.Data[0], 0x06)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.ChannelID {
+		reply, _ := protocol.GenerateAntpacket(protocol.ChannelID, cmd.Data[0], 0x34, 0x12, 0x78, 0x01)
+		return []*protocol.Antpacket{reply}
+	}
+	if cmd.ID == protocol.AcknowledgeData {
+		code := protocol.EventTransferTxCompleted
+		if cmd.Data[1] == 0xFF {
+			code = protocol.EventTransferTxFailed
+		}
+		event, _ := protocol.GenerateAntpacket(protocol.ChannelResponseOrEvent, cmd.Data[0], 0x01, byte(code))
+		return []*protocol.Antpacket{event}
+	}
+	return defaultReplies(cmd)
+}
+
+func TestChannelLifecycle(t *testing.T) {
+	stick, transport := newFakeStick(t, channelReplies)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	c, err := antbuf.AssignChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error assigning channel, ", err)
+	}
+	if c.State() != ChannelAssigned {
+		t.Fatal("Expected assigned channel, got ", c.State())
+	}
+	if _, err = antbuf.AssignChannel(0x01, devicetype.Weighscale); err != ErrChannelInUse {
+		t.Fatal("Expected ErrChannelInUse, got ", err)
+	}
+
+	// Misuse is rejected without talking to the stick
+	stick.ids()
+	if err = c.Close(); err != ErrChannelState {
+		t.Fatal("Expected ErrChannelState closing an assigned channel, got ", err)
+	}
+	if err = c.SendBroadcast(make([]byte, 8)); err != ErrChannelState {
+		t.Fatal("Expected ErrChannelState sending on an assigned channel, got ", err)
+	}
+	if err = c.Open(); err != nil {
+		t.Fatal("Error opening channel, ", err)
+	}
+	if err = c.Open(); err != ErrChannelState {
+		t.Fatal("Expected ErrChannelState opening twice, got ", err)
+	}
+	if err = c.SendBroadcast(make([]byte, 4)); err != ErrPayloadLength {
+		t.Fatal("Expected ErrPayloadLength, got ", err)
+	}
+	if got := stick.ids(); !bytes.Equal(got, []byte{protocol.OpenChannel}) {
+		t.Fatalf("Unexpected packets sent % X", got)
+	}
+
+	status, err := c.Status()
+	if err != nil {
+		t.Fatal("Error getting status, ", err)
+	}
+	if status.State != RadioSearching || status.Network != 1 || status.ChannelType != 0 {
+		t.Fatal("Unexpected status, ", status)
+	}
+	id, err := c.ID()
+	if err != nil {
+		t.Fatal("Error getting channel id, ", err)
+	}
+	if id.DeviceNumber != 0x1234 || id.DeviceType != 0x78 || id.TransmissionType != 0x01 {
+		t.Fatal("Unexpected channel id, ", id)
+	}
+
+	// Received data arrives typed on the event stream
+	pkt, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x01, 1, 2, 3, 4, 5, 6, 7, 8)
+	stick.send(pkt)
+	ev := <-c.Events()
+	if ev.ID != protocol.BroadcastData || !bytes.Equal(ev.Payload, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatal("Unexpected event, ", ev)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal("Error closing channel, ", err)
+	}
+	ev = <-c.Events()
+	if ev.Response == nil || ev.Response.Code != protocol.EventChannelClosed {
+		t.Fatal("Expected EVENT_CHANNEL_CLOSED on the event stream, got ", ev)
+	}
+	if err = c.Unassign(); err != nil {
+		t.Fatal("Error unassigning channel, ", err)
+	}
+	for range c.Events() {
+	}
+	if err = c.Unassign(); err != ErrChannelState {
+		t.Fatal("Expected ErrChannelState unassigning twice, got ", err)
+	}
+
+	// The number is free again
+	if _, err = antbuf.AssignChannel(0x01, devicetype.Weighscale); err != nil {
+		t.Fatal("Error reassigning channel, ", err)
+	}
+}
+
+func TestSendAcknowledged(t *testing.T) {
+	stick, transport := newFakeStick(t, channelReplies)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	if err = c.SendAcknowledged(make([]byte, 8)); err != nil {
+		t.Fatal("Error sending acknowledged data, ", err)
+	}
+	if err = c.SendAcknowledged([]byte{0xFF, 0, 0, 0, 0, 0, 0, 0}); err != ErrTransferFailed {
+		t.Fatal("Expected ErrTransferFailed, got ", err)
+	}
+}
+
+func TestConcurrentSendAcknowledged(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.AcknowledgeData && cmd.Data[1] == 0xFF {
+			// The other end takes its time to fail
+			time.Sleep(5 * time.Millisecond)
+		}
+		return channelReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	// Each transfer gets its own outcome
+	for i := 0; i < 10; i++ {
+		failed := make(chan error)
+		go func() {
+			failed <- c.SendAcknowledged([]byte{0xFF, 0, 0, 0, 0, 0, 0, 0})
+		}()
+		if err = c.SendAcknowledged(make([]byte, 8)); err != nil {
+			t.Fatal("Acknowledged transfer failed, ", err)
+		}
+		if err = <-failed; err != ErrTransferFailed {
+			t.Fatal("Expected ErrTransferFailed, got ", err)
+		}
+	}
+}
+
+func TestSendAcknowledgedRefused(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.AcknowledgeData {
+			return []*protocol.Antpacket{response(cmd, byte(protocol.TransferInProgress))}
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	err = c.SendAcknowledged(make([]byte, 8))
+	var rerr *ResponseError
+	if !errors.As(err, &rerr) || rerr.Code != protocol.TransferInProgress {
+		t.Fatal("Expected TRANSFER_IN_PROGRESS, got ", err)
+	}
+}
+
+func TestAllocateChannel(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	if err = antbuf.ReserveChannels(0, 8); err != ErrChannelOutOfRange {
+		t.Fatal("Expected ErrChannelOutOfRange reserving beyond the stick, got ", err)
+	}
+	if err = antbuf.ReserveChannels(0, 2); err != nil {
+		t.Fatal("Error reserving channels, ", err)
+	}
+
+	channels := make(map[byte]*Channel)
+	for _, expected := range []byte{1, 3, 4, 5, 6, 7} {
+		c, err := antbuf.AllocateChannel(devicetype.Heartrate)
+		if err != nil {
+			t.Fatal("Error allocating channel, ", err)
+		}
+		if c.Number() != expected {
+			t.Fatal("Allocated channel ", c.Number(), ", expected ", expected)
+		}
+		channels[c.Number()] = c
+	}
+	if _, err = antbuf.AllocateChannel(devicetype.Heartrate); err != ErrNoFreeChannels {
+		t.Fatal("Expected ErrNoFreeChannels, got ", err)
+	}
+
+	// Reserved numbers can still be asked for
+	if _, err = antbuf.AssignChannel(2, devicetype.Heartrate); err != nil {
+		t.Fatal("Error assigning reserved channel, ", err)
+	}
+
+	// Unassigned and unreserved numbers are handed out again
+	if err = channels[4].Unassign(); err != nil {
+		t.Fatal("Error unassigning channel, ", err)
+	}
+	antbuf.UnreserveChannels(0)
+	for _, expected := range []byte{0, 4} {
+		c, err := antbuf.AllocateChannel(devicetype.Heartrate)
+		if err != nil {
+			t.Fatal("Error allocating channel, ", err)
+		}
+		if c.Number() != expected {
+			t.Fatal("Allocated channel ", c.Number(), ", expected ", expected)
+		}
+	}
+}
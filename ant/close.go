@@ -0,0 +1,102 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/protocol"
+	"sort"
+)
+
+// channelsIn returns the channels in the given state, in ascending order of number.
+func (a *Antbuffer) channelsIn(state ChannelState) []*Channel {
+	a.channelsLock.Lock()
+	defer a.channelsLock.Unlock()
+
+	var channels []*Channel
+	for _, c := range a.channels {
+		if c.state == state {
+			channels = append(channels, c)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].number < channels[j].number })
+	return channels
+}
+
+// trackChannelEvent notices channels closed by the stick itself, e.g. after a search timeout.
+func (a *Antbuffer) trackChannelEvent(pkt *protocol.Antpacket) {
+	resp, err := protocol.DecodeChannelResponse(pkt)
+	if err != nil || !resp.IsEvent() || resp.Code != protocol.EventChannelClosed {
+		return
+	}
+
+	a.channelsLock.Lock()
+	defer a.channelsLock.Unlock()
+	if c, ok := a.channels[resp.Channel]; ok && c.state == ChannelOpen {
+		c.state = ChannelAssigned
+	}
+}
+
+// Close tears down the Antbuffer.
+//
+// Every open channel is closed, waiting for EVENT_CHANNEL_CLOSED up to the close
+// timeout, and every assigned channel is unassigned. The stick is then reset if
+// the Antbuffer was created WithResetOnClose, the daemons are stopped and the
+// transport is closed. The first error encountered is returned, but teardown
+// always runs to completion.
+func (a *Antbuffer) Close() error {
+	return a.CloseContext(context.Background())
+}
+
+// CloseContext is Close with the conversation with the stick bounded by ctx.
+// The daemons are stopped and the transport closed even if ctx is done.
+func (a *Antbuffer) CloseContext(ctx context.Context) error {
+	var err error = ErrAntbufferClosed
+	a.closeOnce.Do(func() {
+		err = a.teardown(ctx)
+	})
+	return err
+}
+
+func (a *Antbuffer) teardown(ctx context.Context) error {
+	var firstErr error
+	keep := func(err error) {
+		if firstErr == nil && err != nil {
+			firstErr = err
+		}
+	}
+
+	for _, c := range a.channelsIn(ChannelOpen) {
+		keep(c.CloseContext(ctx))
+	}
+
+	for _, c := range a.channelsIn(ChannelAssigned) {
+		keep(c.UnassignContext(ctx))
+	}
+
+	if a.resetOnClose {
+		_, err := a.GenSendAndWaitContext(ctx, protocol.SystemReset, 0)
+		keep(err)
+	}
+
+	keep(a.stop())
+
+	// End the event streams of channels the stick failed to release
+	for _, state := range []ChannelState{ChannelOpen, ChannelAssigned} {
+		for _, c := range a.channelsIn(state) {
+			c.opLock.Lock()
+			if c.State() != ChannelUnassigned {
+				c.release()
+			}
+			c.opLock.Unlock()
+		}
+	}
+	return firstErr
+}
+
+// stop halts the daemons and releases the transport.
+func (a *Antbuffer) stop() error {
+	a.fail(ErrAntbufferClosed)
+	// Closing the transport unblocks the read daemon
+	err := a.transport.Close()
+	a.daemons.Wait()
+	return err
+}
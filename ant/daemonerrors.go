@@ -0,0 +1,94 @@
+package ant
+
+import (
+	"errors"
+	"fmt"
+)
+
+// An ErrorKind classifies a failure on the read or write path.
+type ErrorKind int
+
+const (
+	// ErrorTransient failures may clear up on their own; the Antbuffer keeps running.
+	ErrorTransient ErrorKind = iota
+	// ErrorDeviceGone means the stick disappeared; the Antbuffer stops and must be recreated.
+	ErrorDeviceGone
+	// ErrorProtocol means the stick sent something which could not be understood.
+	ErrorProtocol
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorTransient:
+		return "transient"
+	case ErrorDeviceGone:
+		return "device gone"
+	case ErrorProtocol:
+		return "protocol error"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// A DaemonError is a failure of the read or write daemon reported through Errors.
+type DaemonError struct {
+	Kind ErrorKind
+	// Op is "read" or "write"
+	Op  string
+	Err error
+}
+
+func (d *DaemonError) Error() string {
+	return fmt.Sprintf("%s %s: %v", d.Op, d.Kind, d.Err)
+}
+
+func (d *DaemonError) Unwrap() error {
+	return d.Err
+}
+
+// classify wraps an error from the transport into a DaemonError.
+func classify(op string, err error) *DaemonError {
+	kind := ErrorTransient
+	if errors.Is(err, ErrDeviceGone) {
+		kind = ErrorDeviceGone
+	}
+	return &DaemonError{kind, op, err}
+}
+
+// report publishes an error on the Errors channel, dropping it if nobody is keeping up.
+func (a *Antbuffer) report(err *DaemonError) {
+	select {
+	case a.errs <- err:
+	default:
+	}
+}
+
+// fail stops the Antbuffer because of err. The transport is left for Close to release.
+func (a *Antbuffer) fail(err error) {
+	a.errLock.Lock()
+	if a.err == nil {
+		a.err = err
+	}
+	a.errLock.Unlock()
+
+	a.quitOnce.Do(func() { close(a.quit) })
+}
+
+// Errors returns a channel receiving every failure of the read and write daemons.
+// Errors are dropped when the channel is full.
+func (a *Antbuffer) Errors() <-chan error {
+	return a.errs
+}
+
+// Done returns a channel which is closed once the daemons have stopped, either
+// because the Antbuffer was closed or because the stick has gone.
+func (a *Antbuffer) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err returns nil while the Antbuffer is running. Once Done is closed it returns
+// the error which stopped it, ErrAntbufferClosed after Close.
+func (a *Antbuffer) Err() error {
+	a.errLock.Lock()
+	defer a.errLock.Unlock()
+	return a.err
+}
@@ -0,0 +1,62 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// A USBIdentifier is a Transport to a USB device which can report the device's ids.
+type USBIdentifier interface {
+	USBIDs() (vendor, product uint16)
+}
+
+// DeviceInfo identifies an ant stick.
+type DeviceInfo struct {
+	// Version is the firmware version string, e.g. "AJK1.04RAF"
+	Version string
+	// SerialNumber is zero if the stick has none
+	SerialNumber uint32
+	// VendorID and ProductID are zero unless the transport is USB
+	VendorID     uint16
+	ProductID    uint16
+	Capabilities Capabilities
+}
+
+// DeviceInfo asks the stick for its version and serial number.
+func (a *Antbuffer) DeviceInfo() (DeviceInfo, error) {
+	return a.DeviceInfoContext(context.Background())
+}
+
+// DeviceInfoContext is DeviceInfo bounded by ctx.
+func (a *Antbuffer) DeviceInfoContext(ctx context.Context) (DeviceInfo, error) {
+	info := DeviceInfo{Capabilities: a.capabilities}
+
+	reply, err := a.SendAndWaitContext(ctx, &message.RequestMessage{MessageID: protocol.ANTVersion})
+	if err != nil {
+		return info, err
+	}
+	var version message.ANTVersion
+	if err = version.Unmarshal(reply); err != nil {
+		return info, err
+	}
+	info.Version = version.Version
+
+	if a.capabilities.SerialNumber() {
+		reply, err = a.SendAndWaitContext(ctx, &message.RequestMessage{MessageID: protocol.SerialNumber})
+		if err != nil {
+			return info, err
+		}
+		var serial message.SerialNumber
+		if err = serial.Unmarshal(reply); err != nil {
+			return info, err
+		}
+		info.SerialNumber = serial.SerialNumber
+	}
+
+	if usb, ok := a.transport.(USBIdentifier); ok {
+		info.VendorID, info.ProductID = usb.USBIDs()
+	}
+
+	return info, nil
+}
@@ -0,0 +1,137 @@
+package ant
+
+import (
+	"github.com/Fumon/go-ant/protocol"
+	"log"
+)
+
+// Wildcards for RegisterHandler
+const (
+	AnyChannel = -1
+	AnyClass   = -1
+)
+
+// A handler is a subscriber to packets of one class, or of any class.
+// receive is called from the read daemon and must not block.
+type handler struct {
+	class   int
+	receive func(*protocol.Antpacket)
+}
+
+// RegisterHandler registers a handler on a channel for a specific class of ant packets.
+//
+// Either channel or class may be the wildcard AnyChannel or AnyClass. Every
+// matching handler receives the packet; packets matched by no handler go to
+// the unclaimed handlers, or are left for Wait. Delivery never blocks the
+// Antbuffer, so a handler whose channel is full misses packets. The returned
+// function unregisters the handler.
+func (a *Antbuffer) RegisterHandler(channel int, class int, receiving chan<- *protocol.Antpacket) (func(), error) {
+	return a.registerHandler(channel, class, sendTo(receiving))
+}
+
+// RegisterUnclaimedHandler registers a handler for the packets no handler
+// registered with RegisterHandler receives, whatever their channel or class.
+// While any are registered, unclaimed packets no longer reach Wait. Delivery
+// never blocks the Antbuffer. The returned function unregisters the handler.
+func (a *Antbuffer) RegisterUnclaimedHandler(receiving chan<- *protocol.Antpacket) func() {
+	h := &handler{AnyClass, sendTo(receiving)}
+
+	a.handlersLock.Lock()
+	a.unclaimedListenners = append(a.unclaimedListenners, h)
+	a.handlersLock.Unlock()
+
+	return func() {
+		a.handlersLock.Lock()
+		a.unclaimedListenners = removeHandler(a.unclaimedListenners, h)
+		a.handlersLock.Unlock()
+	}
+}
+
+// sendTo makes a receive func passing packets to receiving without blocking.
+func sendTo(receiving chan<- *protocol.Antpacket) func(*protocol.Antpacket) {
+	return func(pkt *protocol.Antpacket) {
+		select {
+		case receiving <- pkt:
+		default:
+			log.Println("Handler full, dropped packet: ", pkt.Describe(protocol.ANTToHost))
+		}
+	}
+}
+
+func (a *Antbuffer) registerHandler(channel int, class int, receive func(*protocol.Antpacket)) (func(), error) {
+	h := &handler{class, receive}
+
+	a.handlersLock.Lock()
+	defer a.handlersLock.Unlock()
+
+	switch {
+	case channel == AnyChannel:
+		a.anyChannelListenners = append(a.anyChannelListenners, h)
+	case channel >= 0 && channel < len(a.channelListenners):
+		a.channelListenners[channel] = append(a.channelListenners[channel], h)
+	default:
+		return nil, ErrChannelOutOfRange
+	}
+
+	return func() { a.unregisterHandler(channel, h) }, nil
+}
+
+func (a *Antbuffer) unregisterHandler(channel int, h *handler) {
+	a.handlersLock.Lock()
+	defer a.handlersLock.Unlock()
+
+	if channel == AnyChannel {
+		a.anyChannelListenners = removeHandler(a.anyChannelListenners, h)
+	} else {
+		a.channelListenners[channel] = removeHandler(a.channelListenners[channel], h)
+	}
+}
+
+func removeHandler(handlers []*handler, h *handler) []*handler {
+	for i, x := range handlers {
+		if x == h {
+			return append(handlers[:i:i], handlers[i+1:]...)
+		}
+	}
+	return handlers
+}
+
+// dispatch routes a packet to every handler registered for it. Packets nobody
+// registered for go to the unclaimed handlers, or to Wait if there are none.
+func (a *Antbuffer) dispatch(pkt *protocol.Antpacket) {
+	claimed := false
+
+	a.handlersLock.RLock()
+	if channel, ok := pkt.Channel(); ok && int(channel) < len(a.channelListenners) {
+		claimed = deliver(a.channelListenners[channel], pkt) || claimed
+	}
+	claimed = deliver(a.anyChannelListenners, pkt) || claimed
+	if !claimed {
+		claimed = deliver(a.unclaimedListenners, pkt)
+	}
+	a.handlersLock.RUnlock()
+
+	if claimed {
+		return
+	}
+
+	select {
+	case a.readChan <- pkt:
+	default:
+		log.Println("Dropped unclaimed packet: ", pkt.Describe(protocol.ANTToHost))
+	}
+}
+
+// deliver sends pkt to the handlers interested in its class.
+// Returns true if any handler was interested.
+func deliver(handlers []*handler, pkt *protocol.Antpacket) bool {
+	claimed := false
+	for _, h := range handlers {
+		if h.class != AnyClass && h.class != int(pkt.ID) {
+			continue
+		}
+		claimed = true
+		h.receive(pkt)
+	}
+	return claimed
+}
@@ -0,0 +1,35 @@
+package ant
+
+type anterror string
+
+func (a anterror) Error() string {
+	return string(a)
+}
+
+// Errors
+const (
+	ErrChannelOutOfRange     = anterror("Channel number is out of range")
+	ErrChannelInUse          = anterror("Channel number is already assigned")
+	ErrNoFreeChannels        = anterror("Every channel of the ant stick is in use or reserved")
+	ErrChannelState          = anterror("Channel is not in the right state for that")
+	ErrPayloadLength         = anterror("Channel data payload must be 8 bytes")
+	ErrTransferFailed        = anterror("Transfer was not acknowledged")
+	ErrEmptyBurst            = anterror("Burst transfer has no data")
+	ErrBurstSequence         = anterror("Burst packet received out of sequence")
+	ErrNotSupported          = anterror("Ant stick does not support that")
+	ErrNetworkKeyLength      = anterror("Network key not of correct length")
+	ErrNetworkKeyHex         = anterror("Network key is not valid hex")
+	ErrNetworkKeyPermissions = anterror("Network key file is accessible to other users")
+	ErrNetworkKeyMismatch    = anterror("Network key file does not hold the expected key")
+	ErrNoNetworkKey          = anterror("No network key source given")
+	ErrNoFreeNetworks        = anterror("Every network of the ant stick is in use")
+	ErrUnknownNetwork        = anterror("Network name is not registered")
+	ErrAntTimedout           = anterror("Timed out waiting for a reply from ant stick")
+	ErrAntbufferClosed       = anterror("Antbuffer is closed")
+	ErrTransportTimeout      = anterror("Transport read timed out")
+	ErrTransportClosed       = anterror("Transport is closed")
+	ErrDeviceGone            = anterror("Ant stick is no longer attached")
+	ErrStreamCorrupt         = anterror("Corrupt data received from ant stick")
+	ErrUnsupportedBaudRate   = anterror("Unsupported serial baud rate")
+	ErrSerialUnsupported     = anterror("Serial transport is not supported on this platform")
+)
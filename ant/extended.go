@@ -0,0 +1,64 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/message"
+	"sync/atomic"
+)
+
+// RSSI is the signal strength of a received packet.
+type RSSI struct {
+	MeasurementType byte
+	// Value and Threshold are in dBm
+	Value     int8
+	Threshold int8
+}
+
+// EnableExtendedMessages turns on extended data messages, which carry the id
+// of the sending device, its signal strength and the time of receipt after the
+// payload. See ChannelEvent for where they end up. They stay on across
+// unsolicited resets of the stick.
+func (a *Antbuffer) EnableExtendedMessages(enable bool) error {
+	return a.EnableExtendedMessagesContext(context.Background(), enable)
+}
+
+// EnableExtendedMessagesContext is EnableExtendedMessages bounded by ctx.
+func (a *Antbuffer) EnableExtendedMessagesContext(ctx context.Context, enable bool) error {
+	if !a.capabilities.ExtendedMessages() {
+		return ErrNotSupported
+	}
+	_, err := a.SendAndWaitContext(ctx, &message.EnableExtRXMesgs{Enable: enable})
+	if err != nil {
+		return err
+	}
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&a.extended, v)
+	return nil
+}
+
+// extendedMessages reports whether extended messages were enabled.
+func (a *Antbuffer) extendedMessages() bool {
+	return atomic.LoadInt32(&a.extended) != 0
+}
+
+// setExtended fills in the extended message fields of ev from ext.
+// Undecodable fields are left out, keeping the payload.
+func (ev *ChannelEvent) setExtended(ext []byte) {
+	e, err := message.DecodeExtended(ext)
+	if err != nil || e == nil {
+		return
+	}
+	if e.Has(message.ExtendedChannelID) {
+		ev.DeviceID = &ChannelID{e.DeviceNumber, e.DeviceType, e.TransmissionType}
+	}
+	if e.Has(message.ExtendedRSSI) {
+		ev.RSSI = &RSSI{e.MeasurementType, e.RSSI, e.Threshold}
+	}
+	if e.Has(message.ExtendedTimestamp) {
+		timestamp := e.Timestamp
+		ev.Timestamp = &timestamp
+	}
+}
@@ -0,0 +1,91 @@
+package ant
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+)
+
+func TestExtendedMessages(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	c, err := antbuf.SetupChannel(0x01, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+
+	stick.ids()
+	if err = antbuf.EnableExtendedMessages(true); err != nil {
+		t.Fatal("Error enabling extended messages, ", err)
+	}
+	if got := stick.ids(); !bytes.Equal(got, []byte{protocol.EnableExtRXMesgs}) {
+		t.Fatalf("Unexpected packets sent % X", got)
+	}
+
+	// A wildcard channel hears from a strap it can now tell apart
+	ext := &message.ExtendedData{
+		Flags:        message.ExtendedChannelID | message.ExtendedRSSI | message.ExtendedTimestamp,
+		DeviceNumber: 0x1234, DeviceType: 120, TransmissionType: 1,
+		MeasurementType: 0x20, RSSI: -60, Threshold: -90,
+		Timestamp: 0x4000,
+	}
+	pkt, _ := (&message.BroadcastData{Channel: 0x01, Data: [8]byte{7}, Extended: ext.Bytes()}).Marshal()
+	stick.send(pkt)
+	ev := <-c.Events()
+	if !bytes.Equal(ev.Payload, []byte{7, 0, 0, 0, 0, 0, 0, 0}) {
+		t.Fatalf("Unexpected payload % X", ev.Payload)
+	}
+	if ev.DeviceID == nil || *ev.DeviceID != (ChannelID{0x1234, 120, 1}) {
+		t.Fatal("Unexpected device id, ", ev.DeviceID)
+	}
+	if ev.RSSI == nil || *ev.RSSI != (RSSI{0x20, -60, -90}) {
+		t.Fatal("Unexpected RSSI, ", ev.RSSI)
+	}
+	if ev.Timestamp == nil || *ev.Timestamp != 0x4000 {
+		t.Fatal("Unexpected timestamp, ", ev.Timestamp)
+	}
+
+	// Only the flagged fields are set
+	ext = &message.ExtendedData{Flags: message.ExtendedRSSI, MeasurementType: 0x20, RSSI: -70, Threshold: -90}
+	pkt, _ = (&message.BroadcastData{Channel: 0x01, Extended: ext.Bytes()}).Marshal()
+	stick.send(pkt)
+	ev = <-c.Events()
+	if ev.DeviceID != nil || ev.Timestamp != nil || ev.RSSI == nil || ev.RSSI.Value != -70 {
+		t.Fatal("Unexpected extended fields, ", ev.DeviceID, ev.RSSI, ev.Timestamp)
+	}
+
+	// Standard messages have none
+	pkt, _ = (&message.BroadcastData{Channel: 0x01}).Marshal()
+	stick.send(pkt)
+	ev = <-c.Events()
+	if ev.DeviceID != nil || ev.RSSI != nil || ev.Timestamp != nil {
+		t.Fatal("Extended fields set on a standard message")
+	}
+}
+
+func TestExtendedMessagesUnsupported(t *testing.T) {
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.RequestMessage && cmd.Data[1] == protocol.Capabilities {
+			// No extended messages
+			reply, _ := protocol.GenerateAntpacket(protocol.Capabilities, 8, 3, 0x00, 0xBA, 0x34, 0x00)
+			return []*protocol.Antpacket{reply}
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	if err = antbuf.EnableExtendedMessages(true); err != ErrNotSupported {
+		t.Fatal("Expected ErrNotSupported, got ", err)
+	}
+}
@@ -0,0 +1,67 @@
+package ant
+
+import (
+	"sync"
+)
+
+// loopback is one end of an in-memory Transport pair.
+type loopback struct {
+	in         <-chan []byte
+	out        chan<- []byte
+	closed     chan struct{}
+	peerClosed <-chan struct{}
+	once       sync.Once
+}
+
+// NewLoopback creates a connected pair of in-memory transports.
+// Frames written to one end are read from the other, which makes it possible
+// to drive an Antbuffer from a simulated stick without any hardware.
+func NewLoopback() (host, stick Transport) {
+	toStick := make(chan []byte, 20)
+	toHost := make(chan []byte, 20)
+	hostClosed := make(chan struct{})
+	stickClosed := make(chan struct{})
+
+	host = &loopback{toHost, toStick, hostClosed, stickClosed, sync.Once{}}
+	stick = &loopback{toStick, toHost, stickClosed, hostClosed, sync.Once{}}
+	return
+}
+
+func (l *loopback) ReadFrame() ([]byte, error) {
+	select {
+	case frame := <-l.in:
+		return frame, nil
+	case <-l.closed:
+		return nil, ErrTransportClosed
+	case <-l.peerClosed:
+		return nil, ErrTransportClosed
+	}
+}
+
+func (l *loopback) WriteFrame(frame []byte) error {
+	// Copy so the caller may reuse its buffer
+	buf := make([]byte, len(frame))
+	copy(buf, frame)
+
+	select {
+	case <-l.closed:
+		return ErrTransportClosed
+	case <-l.peerClosed:
+		return ErrTransportClosed
+	default:
+	}
+
+	select {
+	case l.out <- buf:
+		return nil
+	case <-l.closed:
+		return ErrTransportClosed
+	case <-l.peerClosed:
+		return ErrTransportClosed
+	}
+}
+
+func (l *loopback) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
@@ -0,0 +1,105 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/message"
+)
+
+// Names of the well known ant networks
+const (
+	NetworkPublic  = devicetype.NetworkPublic
+	NetworkANTPlus = devicetype.NetworkANTPlus
+	NetworkANTFS   = devicetype.NetworkANTFS
+)
+
+// A network is a network number of the stick and the key loaded into it.
+// A pending network holds its number while its first key is being sent.
+type network struct {
+	number  byte
+	key     []byte
+	pending bool
+}
+
+// SetNetwork loads key into one of the stick's networks and registers it under
+// name, returning the network number. Channels refer to the network by name
+// through their Antdevicetype.
+//
+// A name already registered keeps its number and only has its key replaced.
+// Otherwise the lowest free number is used. Network 0 is the public network,
+// which is always registered. Returns ErrNoFreeNetworks when every network of
+// the stick is taken.
+func (a *Antbuffer) SetNetwork(name string, key []byte) (byte, error) {
+	return a.SetNetworkContext(context.Background(), name, key)
+}
+
+// SetNetworkContext is SetNetwork bounded by ctx.
+func (a *Antbuffer) SetNetworkContext(ctx context.Context, name string, key []byte) (byte, error) {
+	if len(key) != 8 {
+		return 0, ErrNetworkKeyLength
+	}
+
+	// Reserve the number, but don't hold the lock while talking to the stick
+	a.networksLock.Lock()
+	var number byte
+	var reservation *network
+	if n, registered := a.networks[name]; registered {
+		number = n.number
+	} else {
+		var ok bool
+		number, ok = a.freeNetwork()
+		if !ok {
+			a.networksLock.Unlock()
+			return 0, ErrNoFreeNetworks
+		}
+		reservation = &network{number: number, pending: true}
+		a.networks[name] = reservation
+	}
+	a.networksLock.Unlock()
+
+	msg := &message.SetNetwork{Network: number}
+	copy(msg.Key[:], key)
+	_, err := a.SendAndWaitContext(ctx, msg)
+
+	a.networksLock.Lock()
+	defer a.networksLock.Unlock()
+	if err != nil {
+		if reservation != nil && a.networks[name] == reservation {
+			delete(a.networks, name)
+		}
+		return 0, err
+	}
+	a.networks[name] = &network{number: number, key: append([]byte(nil), key...)}
+	return number, nil
+}
+
+// freeNetwork finds the lowest network number not registered or reserved.
+// The networksLock must be held.
+func (a *Antbuffer) freeNetwork() (byte, bool) {
+	used := make(map[byte]bool)
+	for _, n := range a.networks {
+		used[n.number] = true
+	}
+	for number := byte(0); number < a.capabilities.MaxNetworks; number++ {
+		if !used[number] {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// Network returns the number of the network registered under name.
+// An empty name is the public network.
+func (a *Antbuffer) Network(name string) (byte, bool) {
+	if name == "" {
+		name = NetworkPublic
+	}
+
+	a.networksLock.Lock()
+	defer a.networksLock.Unlock()
+	n, ok := a.networks[name]
+	if !ok || n.pending {
+		return 0, false
+	}
+	return n.number, true
+}
@@ -0,0 +1,113 @@
+package ant
+
+import (
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+)
+
+func TestNetworks(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	if number, ok := antbuf.Network(NetworkANTPlus); !ok || number != 1 {
+		t.Fatal("ANT+ key was not loaded into network 1, ", number, ok)
+	}
+
+	// The stick has 3 networks, the public one and two keyed ones
+	number, err := antbuf.SetNetwork(NetworkANTFS, make([]byte, 8))
+	if err != nil || number != 2 {
+		t.Fatal("ANT-FS key was not loaded into network 2, ", number, err)
+	}
+	if _, err = antbuf.SetNetwork("private", make([]byte, 8)); err != ErrNoFreeNetworks {
+		t.Fatal("Expected ErrNoFreeNetworks, got ", err)
+	}
+	if number, err = antbuf.SetNetwork(NetworkANTPlus, make([]byte, 8)); err != nil || number != 1 {
+		t.Fatal("Replacing a key moved the network, ", number, err)
+	}
+	if _, err = antbuf.SetNetwork("private", make([]byte, 7)); err != ErrNetworkKeyLength {
+		t.Fatal("Expected ErrNetworkKeyLength, got ", err)
+	}
+
+	// Channels are assigned to their device type's network
+	stick.ids()
+	antfs := *devicetype.Heartrate
+	antfs.Network = NetworkANTFS
+	if _, err = antbuf.AssignChannel(0x01, &antfs); err != nil {
+		t.Fatal("Error assigning channel, ", err)
+	}
+	assign := <-stick.received
+	if assign.ID != protocol.AssignChannel || assign.Data[2] != 2 {
+		t.Fatal("Channel not assigned to the ANT-FS network, ", assign)
+	}
+
+	unknown := *devicetype.Heartrate
+	unknown.Network = "private"
+	if _, err = antbuf.AssignChannel(0x02, &unknown); err != ErrUnknownNetwork {
+		t.Fatal("Expected ErrUnknownNetwork, got ", err)
+	}
+}
+
+func TestSetNetworkReservesNumber(t *testing.T) {
+	sending := make(chan bool)
+	release := make(chan bool)
+	refuse := make(chan bool, 1)
+	stick, transport := newFakeStick(t, func(cmd *protocol.Antpacket) []*protocol.Antpacket {
+		if cmd.ID == protocol.SetNetwork && cmd.Data[0] == 2 {
+			select {
+			case <-refuse:
+				return []*protocol.Antpacket{response(cmd, byte(protocol.InvalidMessage))}
+			default:
+			}
+			// Keep the key loading going until released
+			sending <- true
+			<-release
+		}
+		return defaultReplies(cmd)
+	})
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+
+	// A failed load gives its number back
+	refuse <- true
+	if _, err = antbuf.SetNetwork(NetworkANTFS, make([]byte, 8)); err == nil {
+		t.Fatal("Refused key was registered")
+	}
+	if _, ok := antbuf.Network(NetworkANTFS); ok {
+		t.Fatal("Refused network is registered")
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := antbuf.SetNetwork(NetworkANTFS, make([]byte, 8))
+		done <- err
+	}()
+	<-sending
+
+	// Lookups carry on while the key is sent, and its number is taken
+	if number, ok := antbuf.Network(NetworkANTPlus); !ok || number != 1 {
+		t.Fatal("ANT+ network lookup failed, ", number, ok)
+	}
+	if _, ok := antbuf.Network(NetworkANTFS); ok {
+		t.Fatal("Network registered before its key was loaded")
+	}
+	if _, err = antbuf.SetNetwork("private", make([]byte, 8)); err != ErrNoFreeNetworks {
+		t.Fatal("Expected ErrNoFreeNetworks while the number is reserved, got ", err)
+	}
+
+	close(release)
+	if err = <-done; err != nil {
+		t.Fatal("Error loading ANT-FS key, ", err)
+	}
+	if number, ok := antbuf.Network(NetworkANTFS); !ok || number != 2 {
+		t.Fatal("ANT-FS key was not loaded into network 2, ", number, ok)
+	}
+}
@@ -0,0 +1,141 @@
+package ant
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Where network keys are looked for by default
+const (
+	DefaultNetworkKeyPath = "/etc/ant/antPlusNetworkKey"
+	DefaultNetworkKeyEnv  = "ANT_NETWORK_KEY"
+)
+
+// A NetworkKeySource says where to find a network key. The first of Hex, the
+// environment variable Env and the file at Path which is set is used.
+type NetworkKeySource struct {
+	// Hex is a hex encoded key, e.g. from a config entry
+	Hex string
+	// Env names an environment variable holding a hex encoded key
+	Env string
+	// Path of a key file, see ReadNetworkKeyFile
+	Path string
+}
+
+// Load finds the network key. Errors say which source was at fault.
+func (s NetworkKeySource) Load() ([]byte, error) {
+	switch {
+	case s.Hex != "":
+		key, err := ParseNetworkKey(s.Hex)
+		if err != nil {
+			return nil, fmt.Errorf("network key from config: %w", err)
+		}
+		return key, nil
+	case s.Env != "" && os.Getenv(s.Env) != "":
+		key, err := ParseNetworkKey(os.Getenv(s.Env))
+		if err != nil {
+			return nil, fmt.Errorf("network key from $%s: %w", s.Env, err)
+		}
+		return key, nil
+	case s.Path != "":
+		return ReadNetworkKeyFile(s.Path)
+	}
+	return nil, fmt.Errorf("network key: %w", ErrNoNetworkKey)
+}
+
+// ParseNetworkKey decodes a hex encoded network key. The bytes may be
+// separated by spaces, colons or commas and may carry a 0x prefix, as in
+// "01 23 45 67 89 AB CD EF" or "0x01,0x23,...".
+func ParseNetworkKey(s string) ([]byte, error) {
+	s = strings.NewReplacer("0x", "", "0X", "", " ", "", ":", "", ",", "", "\t", "", "\n", "", "\r", "").Replace(s)
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNetworkKeyHex, err)
+	}
+	if len(key) != 8 {
+		return nil, fmt.Errorf("%w: got %d bytes, need 8", ErrNetworkKeyLength, len(key))
+	}
+	return key, nil
+}
+
+// ReadNetworkKeyFile reads a key file holding either the 8 raw bytes of the
+// key or the key in hex.
+func ReadNetworkKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("network key file: %w", err)
+	}
+	if len(data) == 8 {
+		return data, nil
+	}
+	key, err := ParseNetworkKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("network key file %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// WriteNetworkKeyFile writes the 8 raw bytes of key to path, readable by its
+// owner only, and verifies the file by reading it back. An existing file is
+// replaced whole.
+func WriteNetworkKeyFile(path string, key []byte) error {
+	if len(key) != 8 {
+		return ErrNetworkKeyLength
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// Write beside the file and move it into place
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(0600)
+	if err == nil {
+		_, err = tmp.Write(key)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return err
+	}
+
+	return VerifyNetworkKeyFile(path, key)
+}
+
+// VerifyNetworkKeyFile checks that the key file at path is readable by its
+// owner only and holds a valid key, equal to key unless nil.
+func VerifyNetworkKeyFile(path string, key []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("network key file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("network key file %s: %w (mode %v)", path, ErrNetworkKeyPermissions, info.Mode().Perm())
+	}
+
+	stored, err := ReadNetworkKeyFile(path)
+	if err != nil {
+		return err
+	}
+	if key != nil && string(stored) != string(key) {
+		return fmt.Errorf("network key file %s: %w", path, ErrNetworkKeyMismatch)
+	}
+	return nil
+}
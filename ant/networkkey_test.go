@@ -0,0 +1,72 @@
+package ant
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testKey = []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+
+func TestParseNetworkKey(t *testing.T) {
+	for _, s := range []string{"0123456789ABCDEF", "01 23 45 67 89 ab cd ef\n", "0x01,0x23,0x45,0x67,0x89,0xAB,0xCD,0xEF", "01:23:45:67:89:AB:CD:EF"} {
+		key, err := ParseNetworkKey(s)
+		if err != nil || !bytes.Equal(key, testKey) {
+			t.Fatalf("Error parsing %q, % X %v", s, key, err)
+		}
+	}
+	if _, err := ParseNetworkKey("0123456789ABCD"); !errors.Is(err, ErrNetworkKeyLength) {
+		t.Fatal("Expected ErrNetworkKeyLength, got ", err)
+	}
+	if _, err := ParseNetworkKey("0123456789ABCDZZ"); !errors.Is(err, ErrNetworkKeyHex) {
+		t.Fatal("Expected ErrNetworkKeyHex, got ", err)
+	}
+}
+
+func TestNetworkKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ant", "key")
+	if err := WriteNetworkKeyFile(path, testKey); err != nil {
+		t.Fatal("Error writing key file, ", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatal("Key file has the wrong permissions, ", info.Mode(), err)
+	}
+	if err = VerifyNetworkKeyFile(path, []byte{1, 2, 3, 4, 5, 6, 7, 8}); !errors.Is(err, ErrNetworkKeyMismatch) {
+		t.Fatal("Expected ErrNetworkKeyMismatch, got ", err)
+	}
+
+	os.Chmod(path, 0644)
+	if err = VerifyNetworkKeyFile(path, nil); !errors.Is(err, ErrNetworkKeyPermissions) {
+		t.Fatal("Expected ErrNetworkKeyPermissions, got ", err)
+	}
+
+	// Hex files are read too
+	os.WriteFile(path, []byte("0123456789ABCDEF\n"), 0600)
+	if key, err := ReadNetworkKeyFile(path); err != nil || !bytes.Equal(key, testKey) {
+		t.Fatal("Error reading hex key file, ", key, err)
+	}
+}
+
+func TestNetworkKeySource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 0600)
+	t.Setenv("TEST_ANT_KEY", "0123456789ABCDEF")
+
+	key, err := NetworkKeySource{Env: "TEST_ANT_KEY", Path: path}.Load()
+	if err != nil || !bytes.Equal(key, testKey) {
+		t.Fatal("Environment did not take precedence over the file, ", key, err)
+	}
+	key, err = NetworkKeySource{Env: "TEST_ANT_KEY_UNSET", Path: path}.Load()
+	if err != nil || key[0] != 1 {
+		t.Fatal("Error falling back to the file, ", key, err)
+	}
+	if _, err = (NetworkKeySource{Hex: "nope", Path: path}).Load(); !errors.Is(err, ErrNetworkKeyHex) {
+		t.Fatal("Expected ErrNetworkKeyHex from config, got ", err)
+	}
+	if _, err = (NetworkKeySource{}).Load(); !errors.Is(err, ErrNoNetworkKey) {
+		t.Fatal("Expected ErrNoNetworkKey, got ", err)
+	}
+}
@@ -0,0 +1,66 @@
+package ant
+
+import (
+	"time"
+)
+
+// Default timeouts of an Antbuffer
+const (
+	DefaultReplyTimeout = 1 * time.Second
+	DefaultWaitTimeout  = 1 * time.Second
+	DefaultCloseTimeout = 2 * time.Second
+	// Acknowledged transfers may be retried by the stick over several periods
+	DefaultTransferTimeout = 5 * time.Second
+)
+
+// DefaultSerialRetries is how many times a command is resent after the stick reports a serial error
+const DefaultSerialRetries = 2
+
+// How long the read daemon pauses after a transient failure
+const transientBackoff = 100 * time.Millisecond
+
+// An Option configures an Antbuffer at creation.
+type Option func(*Antbuffer)
+
+// WithReplyTimeout sets how long each command waits for its reply from the stick.
+func WithReplyTimeout(d time.Duration) Option {
+	return func(a *Antbuffer) {
+		a.replyTimeout = d
+	}
+}
+
+// WithWaitTimeout sets how long Wait blocks before returning ErrAntTimedout.
+func WithWaitTimeout(d time.Duration) Option {
+	return func(a *Antbuffer) {
+		a.waitTimeout = d
+	}
+}
+
+// WithCloseTimeout sets how long Close waits for each channel to report EVENT_CHANNEL_CLOSED.
+func WithCloseTimeout(d time.Duration) Option {
+	return func(a *Antbuffer) {
+		a.closeTimeout = d
+	}
+}
+
+// WithTransferTimeout sets how long SendAcknowledged waits for the transfer to complete.
+func WithTransferTimeout(d time.Duration) Option {
+	return func(a *Antbuffer) {
+		a.transferTimeout = d
+	}
+}
+
+// WithSerialRetries sets how many times a command is resent after the stick
+// reports a serial error before the error is returned.
+func WithSerialRetries(n int) Option {
+	return func(a *Antbuffer) {
+		a.serialRetries = n
+	}
+}
+
+// WithResetOnClose makes Close reset the stick after tearing down its channels.
+func WithResetOnClose(reset bool) Option {
+	return func(a *Antbuffer) {
+		a.resetOnClose = reset
+	}
+}
@@ -0,0 +1,129 @@
+package ant
+
+import (
+	"context"
+	"github.com/Fumon/go-ant/message"
+	"github.com/Fumon/go-ant/protocol"
+	"log"
+	"sort"
+	"sync/atomic"
+)
+
+// A ResetEvent reports that the stick reset without being asked to, losing its
+// network keys and channels. By the time it is sent they have been restored.
+type ResetEvent struct {
+	Reason protocol.StartupReason
+	// Err is the first error met restoring the stick, if any
+	Err error
+}
+
+// Resets returns the stream of unsolicited resets of the stick. Events are
+// dropped if nobody keeps up with them.
+func (a *Antbuffer) Resets() <-chan ResetEvent {
+	return a.resets
+}
+
+// unsolicitedReset starts restoring the stick after it reset by itself.
+// Called by the read daemon for StartupMessages nobody was waiting for.
+func (a *Antbuffer) unsolicitedReset(pkt *protocol.Antpacket) {
+	reason, err := protocol.DecodeStartupMessage(pkt)
+	if err != nil {
+		return
+	}
+	// The stick may announce itself while being brought up
+	if atomic.LoadInt32(&a.ready) == 0 {
+		return
+	}
+
+	log.Println("Stick reset unexpectedly, ", reason)
+	a.daemons.Add(1)
+	go func() {
+		defer a.daemons.Done()
+		a.restore(reason)
+	}()
+}
+
+// restore reloads every network key, re-enables extended messages and replays
+// every channel's configuration, reopening the channels which were open.
+func (a *Antbuffer) restore(reason protocol.StartupReason) {
+	a.restoreLock.Lock()
+	defer a.restoreLock.Unlock()
+
+	ctx := context.Background()
+	var firstErr error
+	keep := func(err error) {
+		if firstErr == nil && err != nil {
+			firstErr = err
+		}
+	}
+
+	for _, n := range a.keyedNetworks() {
+		msg := &message.SetNetwork{Network: n.number}
+		copy(msg.Key[:], n.key)
+		_, err := a.SendAndWaitContext(ctx, msg)
+		keep(err)
+	}
+
+	if a.extendedMessages() {
+		_, err := a.SendAndWaitContext(ctx, &message.EnableExtRXMesgs{Enable: true})
+		keep(err)
+	}
+
+	channels := append(a.channelsIn(ChannelOpen), a.channelsIn(ChannelAssigned)...)
+	sort.Slice(channels, func(i, j int) bool { return channels[i].number < channels[j].number })
+	for _, c := range channels {
+		keep(c.restore(ctx))
+	}
+
+	select {
+	case a.resets <- ResetEvent{reason, firstErr}:
+	default:
+		log.Println("Dropped reset event: ", reason)
+	}
+}
+
+// keyedNetworks returns the registered networks with a key, in ascending order of number.
+func (a *Antbuffer) keyedNetworks() []*network {
+	a.networksLock.Lock()
+	defer a.networksLock.Unlock()
+
+	var networks []*network
+	for _, n := range a.networks {
+		if n.key != nil {
+			networks = append(networks, n)
+		}
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].number < networks[j].number })
+	return networks
+}
+
+// restore sets the channel up again on a stick which has forgotten it. A
+// channel which cannot even be assigned is released, ending its event stream.
+func (c *Channel) restore(ctx context.Context) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	was := c.State()
+	if was == ChannelUnassigned {
+		// Unassigned in the meantime
+		return nil
+	}
+
+	c.setState(ChannelUnassigned)
+	err := c.configure(ctx)
+	if err != nil {
+		if c.State() == ChannelUnassigned {
+			c.release()
+		}
+		return err
+	}
+
+	if was == ChannelOpen {
+		_, err = c.antbuf.GenSendAndWaitContext(ctx, protocol.OpenChannel, c.number)
+		if err != nil {
+			return err
+		}
+		c.setState(ChannelOpen)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ant
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+	"time"
+)
+
+func TestUnsolicitedReset(t *testing.T) {
+	stick, transport := newFakeStick(t, nil)
+	defer stick.transport.Close()
+
+	antbuf, err := NewAntbuffer(transport, make([]byte, 8))
+	if err != nil {
+		t.Fatal("Error creating antbuffer, ", err)
+	}
+	open, err := antbuf.SetupChannel(0x02, devicetype.Heartrate)
+	if err != nil {
+		t.Fatal("Error setting up channel, ", err)
+	}
+	assigned, err := antbuf.AssignChannel(0x01, devicetype.Weighscale)
+	if err != nil {
+		t.Fatal("Error assigning channel, ", err)
+	}
+	stick.ids()
+
+	// The stick browns out
+	startup, _ := protocol.GenerateAntpacket(protocol.StartupMessage, byte(protocol.WatchdogReset))
+	stick.send(startup)
+
+	select {
+	case ev := <-antbuf.Resets():
+		if ev.Reason != protocol.WatchdogReset || ev.Err != nil {
+			t.Fatal("Unexpected reset event, ", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No reset event")
+	}
+
+	expected := []byte{
+		protocol.SetNetwork,
+		protocol.AssignChannel,
+		protocol.SetChannelRFFrequency,
+		protocol.SetChannelID,
+		protocol.SetChannelPeriod,
+		protocol.SetSearchTimeout,
+		protocol.AssignChannel,
+		protocol.SetChannelRFFrequency,
+		protocol.SetChannelID,
+		protocol.SetChannelPeriod,
+		protocol.SetSearchTimeout,
+		protocol.OpenChannel,
+	}
+	if got := stick.ids(); !bytes.Equal(got, expected) {
+		t.Fatalf("Unexpected restore sequence % X, expected % X", got, expected)
+	}
+	if open.State() != ChannelOpen || assigned.State() != ChannelAssigned {
+		t.Fatal("Channel states not restored, ", open.State(), assigned.State())
+	}
+
+	// Listeners keep receiving
+	pkt, _ := protocol.GenerateAntpacket(protocol.BroadcastData, 0x02, 1, 2, 3, 4, 5, 6, 7, 8)
+	stick.send(pkt)
+	for ev := range open.Events() {
+		if ev.ID == protocol.BroadcastData {
+			break
+		}
+	}
+
+	// A reset asked for is not restored
+	if _, err = antbuf.GenSendAndWait(protocol.SystemReset, 0); err != nil {
+		t.Fatal("Error resetting, ", err)
+	}
+	select {
+	case ev := <-antbuf.Resets():
+		t.Fatal("Requested reset raised an event, ", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,150 @@
+//go:build linux
+
+package ant
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Fumon/go-ant/protocol"
+	"io"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Termios flags missing from the syscall package
+const (
+	termiosCBAUD   = 0x100f
+	termiosCRTSCTS = 0x80000000
+)
+
+// How long a serial read waits before reporting ErrTransportTimeout
+const serialPollInterval = 500 * time.Millisecond
+
+var serialBaudRates = map[int]uint32{
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+	460800: syscall.B460800,
+	921600: syscall.B921600,
+}
+
+// SerialConfig describes how to reach an ant module over a UART.
+type SerialConfig struct {
+	// Device is the tty path, e.g. /dev/ttyUSB0 for a CP210x based stick.
+	Device string
+	// BaudRate of the link. ANT modules commonly use 57600 or 115200.
+	BaudRate int
+	// FlowControl enables RTS/CTS hardware flow control.
+	FlowControl bool
+}
+
+// serialTransport is a Transport over a tty in raw mode.
+type serialTransport struct {
+	file *os.File
+}
+
+// NewSerialTransport opens and configures the tty described by config.
+func NewSerialTransport(config SerialConfig) (Transport, error) {
+	baud, ok := serialBaudRates[config.BaudRate]
+	if !ok {
+		return nil, ErrUnsupportedBaudRate
+	}
+
+	file, err := os.OpenFile(config.Device, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setRawMode(file, baud, config.FlowControl)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &serialTransport{file: file}, nil
+}
+
+// setRawMode puts the tty in 8N1 raw mode at the given baud rate.
+func setRawMode(file *os.File, baud uint32, flowControl bool) error {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		var t syscall.Termios
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&t)))
+		if errno != 0 {
+			return
+		}
+
+		t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+			syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF | syscall.IXANY
+		t.Oflag &^= syscall.OPOST
+		t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		t.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.CSTOPB | termiosCBAUD | termiosCRTSCTS
+		t.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL | baud
+		if flowControl {
+			t.Cflag |= termiosCRTSCTS
+		}
+		t.Ispeed = baud
+		t.Ospeed = baud
+		t.Cc[syscall.VMIN] = 1
+		t.Cc[syscall.VTIME] = 0
+
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&t)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ReadFrame returns whatever bytes have arrived on the line.
+func (s *serialTransport) ReadFrame() ([]byte, error) {
+	buf := make([]byte, protocol.MaxDataLength)
+
+	// Bound the read so that closing is noticed
+	s.file.SetReadDeadline(time.Now().Add(serialPollInterval))
+	n, err := s.file.Read(buf)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, ErrTransportTimeout
+	} else if errors.Is(err, os.ErrClosed) {
+		return nil, ErrTransportClosed
+	} else if err != nil {
+		return nil, serialError(err)
+	}
+	return buf[:n], nil
+}
+
+func (s *serialTransport) WriteFrame(frame []byte) error {
+	_, err := s.file.Write(frame)
+	if errors.Is(err, os.ErrClosed) {
+		return ErrTransportClosed
+	} else if err != nil {
+		return serialError(err)
+	}
+	return nil
+}
+
+// serialError marks errors meaning the tty has gone away, e.g. a USB serial adapter unplugged.
+func serialError(err error) error {
+	if err == io.EOF || errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENXIO) || errors.Is(err, syscall.ENODEV) {
+		return fmt.Errorf("%w: %v", ErrDeviceGone, err)
+	}
+	return err
+}
+
+func (s *serialTransport) Close() error {
+	return s.file.Close()
+}
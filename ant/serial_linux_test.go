@@ -0,0 +1,90 @@
+//go:build linux
+
+package ant
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Fumon/go-ant/protocol"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty opens a pseudo-terminal pair, returning the master and the slave's path.
+func openPty(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("Pseudo-terminals unavailable, ", err)
+	}
+
+	var unlock, ptn int32
+	fd := master.Fd()
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		master.Close()
+		t.Skip("Could not unlock pty, ", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptn))); errno != 0 {
+		master.Close()
+		t.Skip("Could not get pty number, ", errno)
+	}
+
+	return master, fmt.Sprintf("/dev/pts/%d", ptn)
+}
+
+func TestSerialTransportUnsupportedBaud(t *testing.T) {
+	_, err := NewSerialTransport(SerialConfig{Device: "/dev/null", BaudRate: 1234})
+	if err != ErrUnsupportedBaudRate {
+		t.Fatal("Expected ErrUnsupportedBaudRate, got ", err)
+	}
+}
+
+func TestSerialTransportPty(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+
+	serial, err := NewSerialTransport(SerialConfig{Device: slave, BaudRate: 57600, FlowControl: true})
+	if err != nil {
+		t.Fatal("Error opening serial transport, ", err)
+	}
+	defer serial.Close()
+
+	// Host -> module
+	reset := encodedPacket(protocol.SystemReset, 0)
+	if err := serial.WriteFrame(reset); err != nil {
+		t.Fatal("Error writing frame, ", err)
+	}
+	got := make([]byte, len(reset))
+	if _, err := master.Read(got); err != nil {
+		t.Fatal("Error reading from pty master, ", err)
+	}
+	if !bytes.Equal(got, reset) {
+		t.Fatalf("Module received % X, expected % X", got, reset)
+	}
+
+	// Module -> host, garbage prefixed and followed by a second packet
+	startup := encodedPacket(protocol.StartupMessage, 0x20)
+	response := encodedPacket(protocol.ChannelResponseOrEvent, 1, protocol.OpenChannel, 0)
+	stream := append([]byte{0x00, 0x13}, startup...)
+	stream = append(stream, response...)
+	if _, err := master.Write(stream); err != nil {
+		t.Fatal("Error writing to pty master, ", err)
+	}
+
+	f := &protocol.Framer{}
+	for _, expected := range []byte{protocol.StartupMessage, protocol.ChannelResponseOrEvent} {
+		pkt := f.Next()
+		for pkt == nil {
+			frame, err := serial.ReadFrame()
+			if err != nil && err != ErrTransportTimeout {
+				t.Fatal("Error reading frame, ", err)
+			}
+			f.Write(frame)
+			pkt = f.Next()
+		}
+		if pkt.ID != expected {
+			t.Fatalf("Read %v, expected id %X", pkt, expected)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package ant
+
+// SerialConfig describes how to reach an ant module over a UART.
+type SerialConfig struct {
+	// Device is the tty path, e.g. /dev/ttyUSB0 for a CP210x based stick.
+	Device string
+	// BaudRate of the link. ANT modules commonly use 57600 or 115200.
+	BaudRate int
+	// FlowControl enables RTS/CTS hardware flow control.
+	FlowControl bool
+}
+
+// NewSerialTransport is only supported on linux.
+func NewSerialTransport(config SerialConfig) (Transport, error) {
+	return nil, ErrSerialUnsupported
+}
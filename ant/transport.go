@@ -0,0 +1,20 @@
+package ant
+
+// A Transport carries raw ant frames between the host and the ant stick.
+//
+// The Antbuffer is built on top of a Transport so that it can run on anything
+// capable of moving bytes to and from a stick: USB bulk endpoints, an in-memory
+// loopback for testing, or any other backend.
+type Transport interface {
+	// ReadFrame blocks until data arrives from the stick and returns it.
+	// The data may hold part of a packet or several packets; the Antbuffer
+	// reassembles them. Returns ErrTransportTimeout if nothing arrived in the
+	// transport's own polling interval, ErrTransportClosed once the
+	// transport is closed, and an error wrapping ErrDeviceGone when the stick
+	// has disappeared. Any other error is treated as transient.
+	ReadFrame() ([]byte, error)
+	// WriteFrame sends an encoded frame to the stick. Errors follow ReadFrame.
+	WriteFrame(frame []byte) error
+	// Close releases the transport. Blocked reads and writes return ErrTransportClosed.
+	Close() error
+}
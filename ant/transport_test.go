@@ -0,0 +1,39 @@
+package ant
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/protocol"
+	"testing"
+)
+
+func TestLoopbackRoundTrip(t *testing.T) {
+	host, stick := NewLoopback()
+	defer host.Close()
+
+	frame := []byte{protocol.SyncByte, 1, protocol.SystemReset, 0, protocol.SyncByte ^ 1 ^ protocol.SystemReset}
+	if err := host.WriteFrame(frame); err != nil {
+		t.Fatal("Error writing frame, ", err)
+	}
+	// The written frame must be copied
+	frame[1] = 0xFF
+
+	got, err := stick.ReadFrame()
+	if err != nil {
+		t.Fatal("Error reading frame, ", err)
+	}
+	if !bytes.Equal(got, []byte{protocol.SyncByte, 1, protocol.SystemReset, 0, protocol.SyncByte ^ 1 ^ protocol.SystemReset}) {
+		t.Fatalf("Frame mismatch: % X", got)
+	}
+}
+
+func TestLoopbackClose(t *testing.T) {
+	host, stick := NewLoopback()
+	stick.Close()
+
+	if _, err := host.ReadFrame(); err != ErrTransportClosed {
+		t.Fatal("Expected ErrTransportClosed reading from closed peer, got ", err)
+	}
+	if err := host.WriteFrame([]byte{0}); err != ErrTransportClosed {
+		t.Fatal("Expected ErrTransportClosed writing to closed peer, got ", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Command antkey provisions the network key file read by the ant commands.
+//
+//	antkey [-file path] KEY     write KEY, given in hex, and verify it
+//	antkey [-file path] -verify [KEY]
+//
+// The key may also be given in $ANT_NETWORK_KEY. The file is written readable
+// by its owner only.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Fumon/go-ant/ant"
+	"log"
+	"os"
+)
+
+var (
+	keyFile = flag.String("file", ant.DefaultNetworkKeyPath, "Network key file to write")
+	verify  = flag.Bool("verify", false, "Only verify the key file, against KEY if given")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-file path] [-verify] [KEY]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+
+	var key []byte
+	var err error
+	switch {
+	case flag.NArg() > 1:
+		flag.Usage()
+		os.Exit(2)
+	case flag.NArg() == 1 || os.Getenv(ant.DefaultNetworkKeyEnv) != "":
+		key, err = ant.NetworkKeySource{Hex: flag.Arg(0), Env: ant.DefaultNetworkKeyEnv}.Load()
+		if err != nil {
+			log.Fatalln("Invalid key, ", err)
+		}
+	case !*verify:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *verify {
+		if err = ant.VerifyNetworkKeyFile(*keyFile, key); err != nil {
+			log.Fatalln("Verification failed, ", err)
+		}
+		log.Println("Key file ", *keyFile, " is valid")
+		return
+	}
+
+	if err = ant.WriteNetworkKeyFile(*keyFile, key); err != nil {
+		log.Fatalln("Error writing key file, ", err)
+	}
+	log.Println("Wrote and verified ", *keyFile)
+}
@@ -0,0 +1,145 @@
+// Command heartrate listens to an ANT+ heart rate strap and logs everything it sends.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Fumon/go-ant/ant"
+	"github.com/Fumon/go-ant/devicetype"
+	"github.com/Fumon/go-ant/usbtransport"
+	"github.com/yokujin/gousb/usb"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+var (
+	serialDevice      = flag.String("serial", "", "Serial device of a UART connected ant module (e.g. /dev/ttyUSB0). The USB stick is used if empty")
+	serialBaud        = flag.Int("baud", 57600, "Baud rate of the serial device")
+	serialFlowControl = flag.Bool("rtscts", false, "Enable RTS/CTS flow control on the serial device")
+	keyFile           = flag.String("keyfile", ant.DefaultNetworkKeyPath, "File holding the ANT+ network key, raw or in hex")
+	keyHex            = flag.String("key", "", "ANT+ network key in hex. Overrides $"+ant.DefaultNetworkKeyEnv+" and -keyfile")
+	extended          = flag.Bool("extended", false, "Enable extended messages to log the strap's device number and signal strength")
+)
+
+func main() {
+	fmt.Println("- Life Begins -")
+
+	flag.Parse()
+
+	var transport ant.Transport
+	if *serialDevice != "" {
+		log.Println("Opening serial device ", *serialDevice, "...")
+		var err error
+		transport, err = ant.NewSerialTransport(ant.SerialConfig{
+			Device:      *serialDevice,
+			BaudRate:    *serialBaud,
+			FlowControl: *serialFlowControl,
+		})
+		if err != nil {
+			log.Fatalln("Error opening serial device, ", err)
+		}
+	} else {
+		// Get context
+		ctx := usb.NewContext()
+		defer ctx.Close()
+
+		ctx.Debug(3)
+
+		log.Println("Opening antstick...")
+		var err error
+		transport, err = usbtransport.Open(ctx)
+		if err != nil {
+			log.Fatalln("Error opening antstick, ", err)
+		}
+	}
+
+	// Get the ant plus network key
+	key, err := ant.NetworkKeySource{
+		Hex:  *keyHex,
+		Env:  ant.DefaultNetworkKeyEnv,
+		Path: *keyFile,
+	}.Load()
+	if err != nil {
+		log.Fatalln("Error getting key, ", err)
+	}
+
+	// Bound the time spent bringing up the stick
+	startup, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Create antbuffer
+	antbuf, err := ant.NewAntbufferContext(startup, transport, key)
+	if err != nil {
+		log.Fatalln("Error in creating antbuffer, ", err)
+	}
+	info, err := antbuf.DeviceInfoContext(startup)
+	if err != nil {
+		log.Fatalln("Error identifying stick, ", err)
+	}
+	log.Printf("Stick %s serial %d (%04x:%04x) has %d channels and %d networks\n",
+		info.Version, info.SerialNumber, info.VendorID, info.ProductID,
+		info.Capabilities.MaxChannels, info.Capabilities.MaxNetworks)
+
+	if *extended {
+		err = antbuf.EnableExtendedMessagesContext(startup, true)
+		if err != nil {
+			log.Fatalln("Error enabling extended messages, ", err)
+		}
+	}
+
+	// ListenForCheststrap
+	// All errors at a higher than channel level are to be handled
+	// by the Antbuffer
+	heartrate, err := antbuf.AllocateChannelContext(startup, devicetype.Heartrate)
+	if err != nil {
+		log.Fatalln("Error listening to Heart Rate sensor, ", err)
+	}
+	err = heartrate.OpenContext(startup)
+	if err != nil {
+		log.Fatalln("Error listening to Heart Rate sensor, ", err)
+	}
+	// Close every channel on the way out
+	defer func() {
+		log.Println("Closing antbuffer...")
+		if err := antbuf.Close(); err != nil {
+			log.Println("Error while closing, ", err)
+		}
+	}()
+
+	// TODO: Move this somewhere else
+	// Catch close signal
+	killchan := make(chan os.Signal, 1)
+	signal.Notify(killchan, os.Interrupt, os.Kill)
+
+	// Listen for the heart rate strap forever
+readloop:
+	for {
+		select {
+		case <-killchan:
+			// Die if killed
+			log.Println("Recieved KILL!")
+			break readloop
+		case ev := <-heartrate.Events():
+			log.Println(ev.Packet)
+			if ev.DeviceID != nil {
+				log.Println("From device ", ev.DeviceID.DeviceNumber)
+			}
+			if ev.RSSI != nil {
+				log.Println("RSSI ", ev.RSSI.Value, " dBm")
+			}
+		case ev := <-antbuf.Resets():
+			log.Println("Stick reset (", ev.Reason, "), restored with error ", ev.Err)
+		case err := <-antbuf.Errors():
+			log.Println("Antbuffer error, ", err)
+		case <-antbuf.Done():
+			log.Println("Antbuffer stopped, ", antbuf.Err())
+			break readloop
+		}
+	}
+
+	// Exiting
+	fmt.Println("Exiting...")
+}
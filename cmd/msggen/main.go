@@ -0,0 +1,37 @@
+// Command msggen generates the protocol package's message catalog from message_types.csv.
+//
+//	msggen -in message_types.csv -out protocol/catalog.go
+package main
+
+import (
+	"flag"
+	"github.com/Fumon/go-ant/internal/msggen"
+	"log"
+	"os"
+)
+
+var (
+	in  = flag.String("in", "message_types.csv", "Tab separated message table to read")
+	out = flag.String("out", "catalog.go", "Go file to write")
+	pkg = flag.String("package", "protocol", "Package of the generated file")
+)
+
+func main() {
+	flag.Parse()
+
+	file, err := os.Open(*in)
+	if err != nil {
+		log.Fatalln("Error opening message table, ", err)
+	}
+	defer file.Close()
+
+	src, err := msggen.Generate(file, *pkg)
+	if err != nil {
+		log.Fatalln("Error generating catalog, ", err)
+	}
+
+	err = os.WriteFile(*out, src, 0644)
+	if err != nil {
+		log.Fatalln("Error writing catalog, ", err)
+	}
+}
@@ -0,0 +1,48 @@
+// Package devicetype holds the channel properties of known ant device profiles.
+package devicetype
+
+// Antdevicetype is a convenience struct for defining channel properties
+type Antdevicetype struct {
+	ChannelType      byte
+	RFChannelFreq    byte
+	TransmissionType byte
+	DeviceType       byte
+	DeviceNumber     uint16
+	ChannelPeriod    uint16
+	SearchTimeout    byte
+	// Network is the name of the network the channel is assigned to
+	Network string
+}
+
+// Names of the well known ant networks
+const (
+	NetworkPublic  = "public"
+	NetworkANTPlus = "antplus"
+	NetworkANTFS   = "antfs"
+)
+
+// TODO: new antchannel function
+
+// Weighscale is the ANT+ weight scale profile
+var Weighscale *Antdevicetype = &Antdevicetype{
+	0x00,
+	57,
+	0,
+	119,
+	0,
+	8192, // 8192 counts
+	0xFF, // Timeout should be as long as possible
+	NetworkANTPlus,
+}
+
+// Heartrate is the ANT+ heart rate monitor profile
+var Heartrate *Antdevicetype = &Antdevicetype{
+	0x00,
+	57,
+	0,
+	120,
+	0,
+	8070, // 8070 counts
+	12,   // Search timeout 30 seconds
+	NetworkANTPlus,
+}
@@ -0,0 +1,301 @@
+// Package msggen generates the protocol message catalog from message_types.csv.
+package msggen
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A section of the spec, which becomes a const block and a message class.
+type section struct {
+	prefix  string
+	class   string
+	comment string
+}
+
+var sections = []section{
+	{"9.5.2.", "Config", "Config Messages, HOST -> ANT"},
+	{"9.5.3.", "Notifications", "Notifications ANT -> HOST"},
+	{"9.5.4.", "Control", "Control Messages HOST->ANT"},
+	{"9.5.5.", "Data", "Data Messages HOST<->ANT"},
+	{"9.5.6.", "Channel / Event Messages", "Channel/Event Messages"},
+	{"9.5.7.", "Requested Response", "Requested Response ANT->HOST"},
+	{"9.5.8.", "Test", "Test Mode HOST->ANT"},
+}
+
+// A name is the Go constant and display name of a message whose CSV type does not make a good one.
+type name struct {
+	constant string
+	display  string
+}
+
+// names is keyed by the CSV type and sender.
+var names = map[string]name{
+	"Channel ID/Host":                   {"SetChannelID", "Set Channel ID"},
+	"Channel ID/ANT":                    {"ChannelID", "Channel ID"},
+	"Channel Period/Host":               {"SetChannelPeriod", "Set Channel Period"},
+	"Search Timeout/Host":               {"SetSearchTimeout", "Set Search Timeout"},
+	"Channel RF Frequency/Host":         {"SetChannelRFFrequency", "Set Channel RF Frequency"},
+	"Transmit Power/Host":               {"SetTransmitPower", "Set Transmit Power"},
+	"Channel Transmit Power/Host":       {"SetChannelTransmitPower", "Set Channel Transmit Power"},
+	"Low Priority Search Timeout/Host":  {"SetLowPrioritySearchTimeout", "Set Low Priority Search Timeout"},
+	"Serial Number Set Channel ID/Host": {"SetSerialNumberSetChannelID", "Set Serial Number Set Channel ID"},
+	"Proximity Search/Host":             {"SetProximitySearch", "Set Proximity Search"},
+	"Channel Search Priority/Host":      {"SetChannelSearchPriority", "Set Channel Search Priority"},
+	"Channel Response / Event/ANT":      {"ChannelResponseOrEvent", "Channel Response / Event"},
+}
+
+// A message is one row of the CSV.
+type message struct {
+	constant  string
+	display   string
+	section   *section
+	class     string
+	ref       string
+	reply     bool
+	direction string
+	length    int
+	maxLength int
+	id        byte
+	fields    []string
+}
+
+// Columns of the CSV
+const (
+	colType = iota
+	colFunction
+	colSection
+	colReply
+	colFrom
+	colLen
+	colMaxLen
+	colID
+	colData
+)
+
+// Generate reads the tab separated message_types.csv and returns the Go
+// source of the catalog for package pkg.
+func Generate(in io.Reader, pkg string) ([]byte, error) {
+	messages, err := parse(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "// Code generated by msggen from message_types.csv. DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "package", pkg)
+
+	for i := range sections {
+		s := &sections[i]
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "//", s.comment)
+		fmt.Fprintln(out, "const (")
+		for _, m := range messages {
+			if m.section == s {
+				fmt.Fprintf(out, "%s = 0x%02X\n", m.constant, m.id)
+			}
+		}
+		fmt.Fprintln(out, ")")
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "// MsgClasses is the catalog of every message class, keyed by message id.")
+	fmt.Fprintln(out, "// Ids shared by a host command and a message from the stick have one merged entry.")
+	fmt.Fprintln(out, "var MsgClasses = map[byte]*MsgClass{")
+	for _, m := range merge(messages) {
+		writeClass(out, m)
+	}
+	fmt.Fprintln(out, "}")
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "// directedClasses holds the class sent each way for ids shared by a host command")
+	fmt.Fprintln(out, "// and a message from the stick.")
+	fmt.Fprintln(out, "var directedClasses = map[Direction]map[byte]*MsgClass{")
+	shared := sharedIDs(messages)
+	for _, dir := range []string{"HostToANT", "ANTToHost"} {
+		fmt.Fprintf(out, "%s: {\n", dir)
+		for _, m := range messages {
+			if shared[m.id] && (m.direction == dir || m.direction == "Bidirectional") {
+				writeClass(out, m)
+			}
+		}
+		fmt.Fprintln(out, "},")
+	}
+	fmt.Fprintln(out, "}")
+
+	return format.Source(out.Bytes())
+}
+
+// writeClass writes the map entry of m's message class.
+func writeClass(out io.Writer, m *message) {
+	fmt.Fprintf(out, "%s: {\n", m.constant)
+	fmt.Fprintf(out, "Name: %q,\n", m.display)
+	fmt.Fprintf(out, "Class: %q,\n", m.class)
+	fmt.Fprintf(out, "Template: AntpacketTemplate{DataLength: %d, MaxLength: %d, ID: 0x%02X},\n", m.length, m.maxLength, m.id)
+	fmt.Fprintf(out, "Direction: %s,\n", m.direction)
+	fmt.Fprintf(out, "Reply: %t,\n", m.reply)
+	fmt.Fprintf(out, "Section: %q,\n", m.ref)
+	fmt.Fprintln(out, "DataFieldDesc: []string{")
+	for _, f := range m.fields {
+		fmt.Fprintf(out, "%q,\n", f)
+	}
+	fmt.Fprintln(out, "},")
+	fmt.Fprintln(out, "},")
+}
+
+// sharedIDs returns the ids used by more than one message.
+func sharedIDs(messages []*message) map[byte]bool {
+	seen := make(map[byte]bool)
+	shared := make(map[byte]bool)
+	for _, m := range messages {
+		if seen[m.id] {
+			shared[m.id] = true
+		}
+		seen[m.id] = true
+	}
+	return shared
+}
+
+func parse(in io.Reader) ([]*message, error) {
+	r := csv.NewReader(in)
+	r.Comma = '\t'
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no messages")
+	}
+
+	var messages []*message
+	for line, record := range records[1:] {
+		m, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("message_types.csv row %d: %v", line+2, err)
+		}
+		if m != nil {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+func parseRecord(record []string) (*message, error) {
+	for len(record) > 0 && strings.TrimSpace(record[len(record)-1]) == "" {
+		record = record[:len(record)-1]
+	}
+	if len(record) == 0 {
+		return nil, nil
+	}
+	if len(record) < colData {
+		return nil, fmt.Errorf("only %d columns", len(record))
+	}
+
+	m := &message{}
+	typ := strings.TrimSpace(record[colType])
+
+	from := strings.ReplaceAll(record[colFrom], " ", "")
+	switch from {
+	case "Host":
+		m.direction = "HostToANT"
+	case "ANT":
+		m.direction = "ANTToHost"
+	case "Host/ANT":
+		m.direction = "Bidirectional"
+	default:
+		return nil, fmt.Errorf("unknown sender %q", record[colFrom])
+	}
+
+	n, ok := names[typ+"/"+from]
+	if !ok {
+		n = name{strings.ReplaceAll(typ, " ", ""), typ}
+	}
+	m.constant, m.display = n.constant, n.display
+
+	m.ref = strings.Fields(record[colSection])[0]
+	for i := range sections {
+		if strings.HasPrefix(m.ref, sections[i].prefix) {
+			m.section = &sections[i]
+		}
+	}
+	if m.section == nil {
+		return nil, fmt.Errorf("%s is in unknown section %s", typ, m.ref)
+	}
+	m.class = m.section.class
+
+	m.reply = strings.TrimSpace(record[colReply]) == "Yes"
+
+	length, err := strconv.Atoi(strings.TrimSpace(record[colLen]))
+	if err != nil {
+		return nil, fmt.Errorf("%s length: %v", typ, err)
+	}
+	m.length = length
+
+	// Optional trailing fields allow for longer messages
+	m.maxLength = length
+	if max := strings.TrimSpace(record[colMaxLen]); max != "" {
+		m.maxLength, err = strconv.Atoi(max)
+		if err != nil {
+			return nil, fmt.Errorf("%s max length: %v", typ, err)
+		}
+		if m.maxLength < m.length {
+			return nil, fmt.Errorf("%s max length %d is less than its length %d", typ, m.maxLength, m.length)
+		}
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSpace(record[colID]), 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("%s id: %v", typ, err)
+	}
+	m.id = byte(id)
+
+	for _, f := range record[colData:] {
+		m.fields = append(m.fields, strings.TrimSpace(f))
+	}
+	return m, nil
+}
+
+// merge folds messages sharing an id into one catalog entry, keeping the order of first appearance.
+func merge(messages []*message) []*message {
+	var merged []*message
+	byID := make(map[byte]*message)
+	for _, m := range messages {
+		first, ok := byID[m.id]
+		if !ok {
+			copied := *m
+			copied.fields = append([]string(nil), m.fields...)
+			byID[m.id] = &copied
+			merged = append(merged, &copied)
+			continue
+		}
+
+		first.display += " / " + m.display
+		first.class += " / " + m.class
+		first.direction = "Bidirectional"
+		first.reply = first.reply || m.reply
+		if m.length < first.length {
+			first.length = m.length
+		}
+		if m.maxLength > first.maxLength {
+			first.maxLength = m.maxLength
+		}
+		first.ref += " / " + m.ref
+		for i, f := range m.fields {
+			if i >= len(first.fields) {
+				first.fields = append(first.fields, f)
+			} else if first.fields[i] != f {
+				first.fields[i] += " / " + f
+			}
+		}
+	}
+	return merged
+}
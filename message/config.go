@@ -0,0 +1,413 @@
+package message
+
+import (
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// UnassignChannel releases a channel.
+type UnassignChannel struct {
+	Channel byte
+}
+
+func (m *UnassignChannel) ID() byte { return protocol.UnassignChannel }
+
+func (m *UnassignChannel) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel)
+}
+
+func (m *UnassignChannel) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	return nil
+}
+
+// AssignChannel reserves a channel with a channel type on a network.
+// ExtendedAssignment is optional and only sent when non-zero.
+type AssignChannel struct {
+	Channel            byte
+	ChannelType        byte
+	Network            byte
+	ExtendedAssignment byte
+}
+
+func (m *AssignChannel) ID() byte { return protocol.AssignChannel }
+
+func (m *AssignChannel) Marshal() (*protocol.Antpacket, error) {
+	if m.ExtendedAssignment != 0 {
+		return protocol.GenerateAntpacket(m.ID(), m.Channel, m.ChannelType, m.Network, m.ExtendedAssignment)
+	}
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.ChannelType, m.Network)
+}
+
+func (m *AssignChannel) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Channel, m.ChannelType, m.Network = pkt.Data[0], pkt.Data[1], pkt.Data[2]
+	m.ExtendedAssignment = 0
+	if len(pkt.Data) > 3 {
+		m.ExtendedAssignment = pkt.Data[3]
+	}
+	return nil
+}
+
+// SetChannelID sets the id of the device a channel talks to. Zeroes are wildcards.
+type SetChannelID struct {
+	Channel          byte
+	DeviceNumber     uint16
+	DeviceType       byte
+	TransmissionType byte
+}
+
+func (m *SetChannelID) ID() byte { return protocol.SetChannelID }
+
+func (m *SetChannelID) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, lsb(m.DeviceNumber), msb(m.DeviceNumber), m.DeviceType, m.TransmissionType)
+}
+
+func (m *SetChannelID) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 5); err != nil {
+		return err
+	}
+	m.Channel, m.DeviceNumber, m.DeviceType, m.TransmissionType = pkt.Data[0], uint16At(pkt.Data, 1), pkt.Data[3], pkt.Data[4]
+	return nil
+}
+
+// SetChannelPeriod sets the messaging period of a channel in 1/32768 s.
+type SetChannelPeriod struct {
+	Channel byte
+	Period  uint16
+}
+
+func (m *SetChannelPeriod) ID() byte { return protocol.SetChannelPeriod }
+
+func (m *SetChannelPeriod) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, lsb(m.Period), msb(m.Period))
+}
+
+func (m *SetChannelPeriod) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Channel, m.Period = pkt.Data[0], uint16At(pkt.Data, 1)
+	return nil
+}
+
+// SetSearchTimeout sets the high priority search timeout of a channel in 2.5 s steps.
+type SetSearchTimeout struct {
+	Channel byte
+	Timeout byte
+}
+
+func (m *SetSearchTimeout) ID() byte { return protocol.SetSearchTimeout }
+
+func (m *SetSearchTimeout) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Timeout)
+}
+
+func (m *SetSearchTimeout) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Timeout = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SetChannelRFFrequency sets the frequency of a channel as an offset from 2400 MHz.
+type SetChannelRFFrequency struct {
+	Channel   byte
+	Frequency byte
+}
+
+func (m *SetChannelRFFrequency) ID() byte { return protocol.SetChannelRFFrequency }
+
+func (m *SetChannelRFFrequency) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Frequency)
+}
+
+func (m *SetChannelRFFrequency) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Frequency = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SetNetwork loads a network key.
+type SetNetwork struct {
+	Network byte
+	Key     [8]byte
+}
+
+func (m *SetNetwork) ID() byte { return protocol.SetNetwork }
+
+func (m *SetNetwork) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), append([]byte{m.Network}, m.Key[:]...)...)
+}
+
+func (m *SetNetwork) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 9); err != nil {
+		return err
+	}
+	m.Network = pkt.Data[0]
+	copy(m.Key[:], pkt.Data[1:9])
+	return nil
+}
+
+// SetTransmitPower sets the transmit power of every channel.
+type SetTransmitPower struct {
+	Power byte
+}
+
+func (m *SetTransmitPower) ID() byte { return protocol.SetTransmitPower }
+
+func (m *SetTransmitPower) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0, m.Power)
+}
+
+func (m *SetTransmitPower) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Power = pkt.Data[1]
+	return nil
+}
+
+// IDListAdd puts a device id into the inclusion/exclusion list of a channel.
+type IDListAdd struct {
+	Channel          byte
+	DeviceNumber     uint16
+	DeviceType       byte
+	TransmissionType byte
+	Index            byte
+}
+
+func (m *IDListAdd) ID() byte { return protocol.IDListAdd }
+
+func (m *IDListAdd) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, lsb(m.DeviceNumber), msb(m.DeviceNumber), m.DeviceType, m.TransmissionType, m.Index)
+}
+
+func (m *IDListAdd) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 6); err != nil {
+		return err
+	}
+	m.Channel, m.DeviceNumber, m.DeviceType = pkt.Data[0], uint16At(pkt.Data, 1), pkt.Data[3]
+	m.TransmissionType, m.Index = pkt.Data[4], pkt.Data[5]
+	return nil
+}
+
+// IDListConfig sizes the id list of a channel and makes it an inclusion or exclusion list.
+type IDListConfig struct {
+	Channel byte
+	Size    byte
+	Exclude bool
+}
+
+func (m *IDListConfig) ID() byte { return protocol.IDListConfig }
+
+func (m *IDListConfig) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Size, boolByte(m.Exclude))
+}
+
+func (m *IDListConfig) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Channel, m.Size, m.Exclude = pkt.Data[0], pkt.Data[1], pkt.Data[2] != 0
+	return nil
+}
+
+// SetChannelTransmitPower sets the transmit power of one channel.
+type SetChannelTransmitPower struct {
+	Channel byte
+	Power   byte
+}
+
+func (m *SetChannelTransmitPower) ID() byte { return protocol.SetChannelTransmitPower }
+
+func (m *SetChannelTransmitPower) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Power)
+}
+
+func (m *SetChannelTransmitPower) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Power = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SetLowPrioritySearchTimeout sets the low priority search timeout of a channel in 2.5 s steps.
+type SetLowPrioritySearchTimeout struct {
+	Channel byte
+	Timeout byte
+}
+
+func (m *SetLowPrioritySearchTimeout) ID() byte { return protocol.SetLowPrioritySearchTimeout }
+
+func (m *SetLowPrioritySearchTimeout) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Timeout)
+}
+
+func (m *SetLowPrioritySearchTimeout) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Timeout = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SetSerialNumberSetChannelID sets the channel id using the stick's serial number as device number.
+type SetSerialNumberSetChannelID struct {
+	Channel          byte
+	DeviceType       byte
+	TransmissionType byte
+}
+
+func (m *SetSerialNumberSetChannelID) ID() byte { return protocol.SetSerialNumberSetChannelID }
+
+func (m *SetSerialNumberSetChannelID) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.DeviceType, m.TransmissionType)
+}
+
+func (m *SetSerialNumberSetChannelID) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Channel, m.DeviceType, m.TransmissionType = pkt.Data[0], pkt.Data[1], pkt.Data[2]
+	return nil
+}
+
+// EnableExtRXMesgs turns extended data messages on or off.
+type EnableExtRXMesgs struct {
+	Enable bool
+}
+
+func (m *EnableExtRXMesgs) ID() byte { return protocol.EnableExtRXMesgs }
+
+func (m *EnableExtRXMesgs) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0, boolByte(m.Enable))
+}
+
+func (m *EnableExtRXMesgs) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Enable = pkt.Data[1] != 0
+	return nil
+}
+
+// EnableLED turns the stick's LED on or off.
+type EnableLED struct {
+	Enable bool
+}
+
+func (m *EnableLED) ID() byte { return protocol.EnableLED }
+
+func (m *EnableLED) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0, boolByte(m.Enable))
+}
+
+func (m *EnableLED) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Enable = pkt.Data[1] != 0
+	return nil
+}
+
+// CrystalEnable makes the module use an external 32 kHz crystal.
+type CrystalEnable struct{}
+
+func (m *CrystalEnable) ID() byte { return protocol.CrystalEnable }
+
+func (m *CrystalEnable) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0)
+}
+
+func (m *CrystalEnable) Unmarshal(pkt *protocol.Antpacket) error {
+	return check(pkt, m.ID(), 1)
+}
+
+// LibConfig selects which extended data fields the stick sends.
+type LibConfig struct {
+	Config byte
+}
+
+func (m *LibConfig) ID() byte { return protocol.LibConfig }
+
+func (m *LibConfig) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0, m.Config)
+}
+
+func (m *LibConfig) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Config = pkt.Data[1]
+	return nil
+}
+
+// FrequencyAgility sets the three frequencies a channel may hop between.
+type FrequencyAgility struct {
+	Channel     byte
+	Frequencies [3]byte
+}
+
+func (m *FrequencyAgility) ID() byte { return protocol.FrequencyAgility }
+
+func (m *FrequencyAgility) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Frequencies[0], m.Frequencies[1], m.Frequencies[2])
+}
+
+func (m *FrequencyAgility) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 4); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	copy(m.Frequencies[:], pkt.Data[1:4])
+	return nil
+}
+
+// SetProximitySearch limits a channel's search to devices within a threshold bin.
+type SetProximitySearch struct {
+	Channel   byte
+	Threshold byte
+}
+
+func (m *SetProximitySearch) ID() byte { return protocol.SetProximitySearch }
+
+func (m *SetProximitySearch) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Threshold)
+}
+
+func (m *SetProximitySearch) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Threshold = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SetChannelSearchPriority sets the priority of a channel's search.
+type SetChannelSearchPriority struct {
+	Channel  byte
+	Priority byte
+}
+
+func (m *SetChannelSearchPriority) ID() byte { return protocol.SetChannelSearchPriority }
+
+func (m *SetChannelSearchPriority) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.Priority)
+}
+
+func (m *SetChannelSearchPriority) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.Priority = pkt.Data[0], pkt.Data[1]
+	return nil
+}
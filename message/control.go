@@ -0,0 +1,135 @@
+package message
+
+import (
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// SystemReset resets the stick, which answers with a StartupMessage.
+type SystemReset struct{}
+
+func (m *SystemReset) ID() byte { return protocol.SystemReset }
+
+func (m *SystemReset) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0)
+}
+
+func (m *SystemReset) Unmarshal(pkt *protocol.Antpacket) error {
+	return check(pkt, m.ID(), 1)
+}
+
+// OpenChannel opens an assigned channel.
+type OpenChannel struct {
+	Channel byte
+}
+
+func (m *OpenChannel) ID() byte { return protocol.OpenChannel }
+
+func (m *OpenChannel) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel)
+}
+
+func (m *OpenChannel) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	return nil
+}
+
+// CloseChannel closes an open channel. The stick follows its response with EVENT_CHANNEL_CLOSED.
+type CloseChannel struct {
+	Channel byte
+}
+
+func (m *CloseChannel) ID() byte { return protocol.CloseChannel }
+
+func (m *CloseChannel) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel)
+}
+
+func (m *CloseChannel) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	return nil
+}
+
+// OpenRxScanMode opens channel 0 in continuous scanning mode.
+type OpenRxScanMode struct{}
+
+func (m *OpenRxScanMode) ID() byte { return protocol.OpenRxScanMode }
+
+func (m *OpenRxScanMode) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0)
+}
+
+func (m *OpenRxScanMode) Unmarshal(pkt *protocol.Antpacket) error {
+	return check(pkt, m.ID(), 1)
+}
+
+// RequestMessage asks the stick to send the message MessageID about a channel.
+type RequestMessage struct {
+	Channel   byte
+	MessageID byte
+}
+
+func (m *RequestMessage) ID() byte { return protocol.RequestMessage }
+
+func (m *RequestMessage) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.MessageID)
+}
+
+func (m *RequestMessage) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	m.Channel, m.MessageID = pkt.Data[0], pkt.Data[1]
+	return nil
+}
+
+// SleepMessage puts the module into deep sleep.
+type SleepMessage struct{}
+
+func (m *SleepMessage) ID() byte { return protocol.SleepMessage }
+
+func (m *SleepMessage) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0)
+}
+
+func (m *SleepMessage) Unmarshal(pkt *protocol.Antpacket) error {
+	return check(pkt, m.ID(), 1)
+}
+
+// CWInit prepares the stick for continuous wave test mode.
+type CWInit struct{}
+
+func (m *CWInit) ID() byte { return protocol.CWInit }
+
+func (m *CWInit) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0)
+}
+
+func (m *CWInit) Unmarshal(pkt *protocol.Antpacket) error {
+	return check(pkt, m.ID(), 1)
+}
+
+// CWTest transmits a continuous wave at a power and frequency.
+type CWTest struct {
+	Power     byte
+	Frequency byte
+}
+
+func (m *CWTest) ID() byte { return protocol.CWTest }
+
+func (m *CWTest) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), 0, m.Power, m.Frequency)
+}
+
+func (m *CWTest) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Power, m.Frequency = pkt.Data[1], pkt.Data[2]
+	return nil
+}
@@ -0,0 +1,127 @@
+package message
+
+import (
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// Data messages received with extended messages enabled carry a flag byte and
+// the fields it announces after the 8 data bytes. Extended holds them raw,
+// starting with the flag byte, and is nil for standard messages.
+
+// BroadcastData is 8 bytes of data sent once per channel period.
+type BroadcastData struct {
+	Channel  byte
+	Data     [8]byte
+	Extended []byte
+}
+
+func (m *BroadcastData) ID() byte { return protocol.BroadcastData }
+
+func (m *BroadcastData) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), dataArgs(m.Channel, m.Data, m.Extended)...)
+}
+
+func (m *BroadcastData) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 9); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	copy(m.Data[:], pkt.Data[1:9])
+	m.Extended = extended(pkt)
+	return nil
+}
+
+// AcknowledgeData is 8 bytes of data the receiver acknowledges.
+type AcknowledgeData struct {
+	Channel  byte
+	Data     [8]byte
+	Extended []byte
+}
+
+func (m *AcknowledgeData) ID() byte { return protocol.AcknowledgeData }
+
+func (m *AcknowledgeData) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), dataArgs(m.Channel, m.Data, m.Extended)...)
+}
+
+func (m *AcknowledgeData) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 9); err != nil {
+		return err
+	}
+	m.Channel = pkt.Data[0]
+	copy(m.Data[:], pkt.Data[1:9])
+	m.Extended = extended(pkt)
+	return nil
+}
+
+// BurstTransferData is one 8 byte packet of a burst. The first byte of the
+// packet holds the sequence number in its upper 3 bits and the channel in the
+// lower 5.
+//
+// The sequence number of the first packet of a burst is 0, the rest count 1,
+// 2, 3, 1, ... in the lower 2 bits, and the last packet also has BurstLast set.
+type BurstTransferData struct {
+	Channel  byte
+	Sequence byte
+	Data     [8]byte
+	Extended []byte
+}
+
+func (m *BurstTransferData) ID() byte { return protocol.BurstTransferData }
+
+func (m *BurstTransferData) Marshal() (*protocol.Antpacket, error) {
+	first := m.Sequence<<5 | m.Channel&0x1F
+	return protocol.GenerateAntpacket(m.ID(), dataArgs(first, m.Data, m.Extended)...)
+}
+
+func (m *BurstTransferData) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 9); err != nil {
+		return err
+	}
+	m.Channel, m.Sequence = pkt.Data[0]&0x1F, pkt.Data[0]>>5
+	copy(m.Data[:], pkt.Data[1:9])
+	m.Extended = extended(pkt)
+	return nil
+}
+
+// BurstLast marks the sequence number of the last packet of a burst.
+const BurstLast = 0x04
+
+// BurstSequence returns the sequence number of packet i of a burst of n packets.
+func BurstSequence(i, n int) byte {
+	var seq byte
+	if i > 0 {
+		seq = byte((i-1)%3 + 1)
+	}
+	if i == n-1 {
+		seq |= BurstLast
+	}
+	return seq
+}
+
+// SplitBurst splits data into the packets of a burst on channel, zero padding
+// the last packet to 8 bytes.
+func SplitBurst(channel byte, data []byte) []*BurstTransferData {
+	n := (len(data) + 7) / 8
+	packets := make([]*BurstTransferData, n)
+	for i := range packets {
+		p := &BurstTransferData{Channel: channel, Sequence: BurstSequence(i, n)}
+		copy(p.Data[:], data[i*8:])
+		packets[i] = p
+	}
+	return packets
+}
+
+func dataArgs(first byte, data [8]byte, ext []byte) []byte {
+	args := make([]byte, 0, 9+len(ext))
+	args = append(args, first)
+	args = append(args, data[:]...)
+	return append(args, ext...)
+}
+
+func extended(pkt *protocol.Antpacket) []byte {
+	if len(pkt.Data) <= 9 {
+		return nil
+	}
+	return append([]byte(nil), pkt.Data[9:]...)
+}
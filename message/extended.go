@@ -0,0 +1,92 @@
+package message
+
+import (
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// Flags of the byte following the payload of an extended data message. Each
+// announces a field after it, in the order ChannelID, RSSI, Timestamp.
+const (
+	ExtendedTimestamp = 0x20
+	ExtendedRSSI      = 0x40
+	ExtendedChannelID = 0x80
+)
+
+// ExtendedData is the flagged fields of an extended data message, as held in
+// the Extended bytes of BroadcastData, AcknowledgeData and BurstTransferData.
+// Fields whose flag is not set are zero.
+type ExtendedData struct {
+	Flags byte
+	// ExtendedChannelID
+	DeviceNumber     uint16
+	DeviceType       byte
+	TransmissionType byte
+	// ExtendedRSSI, with the signal strength and search threshold in dBm
+	MeasurementType byte
+	RSSI            int8
+	Threshold       int8
+	// ExtendedTimestamp, in 1/32768 s rolling over every 2 s
+	Timestamp uint16
+}
+
+// Has reports whether flag is set.
+func (e *ExtendedData) Has(flag byte) bool {
+	return e.Flags&flag != 0
+}
+
+// Bytes packs the flag byte and the flagged fields.
+func (e *ExtendedData) Bytes() []byte {
+	ext := []byte{e.Flags}
+	if e.Has(ExtendedChannelID) {
+		ext = append(ext, lsb(e.DeviceNumber), msb(e.DeviceNumber), e.DeviceType, e.TransmissionType)
+	}
+	if e.Has(ExtendedRSSI) {
+		ext = append(ext, e.MeasurementType, byte(e.RSSI), byte(e.Threshold))
+	}
+	if e.Has(ExtendedTimestamp) {
+		ext = append(ext, lsb(e.Timestamp), msb(e.Timestamp))
+	}
+	return ext
+}
+
+// DecodeExtended unpacks the Extended bytes of a data message. It returns nil
+// for a standard message, and protocol.ErrDataLength if the flagged fields do
+// not fit. Flags it does not know are kept but their fields are not decoded.
+func DecodeExtended(ext []byte) (*ExtendedData, error) {
+	if len(ext) == 0 {
+		return nil, nil
+	}
+	e := &ExtendedData{Flags: ext[0]}
+	rest := ext[1:]
+	take := func(n int) ([]byte, error) {
+		if len(rest) < n {
+			return nil, protocol.ErrDataLength
+		}
+		field := rest[:n]
+		rest = rest[n:]
+		return field, nil
+	}
+
+	if e.Has(ExtendedChannelID) {
+		field, err := take(4)
+		if err != nil {
+			return nil, err
+		}
+		e.DeviceNumber, e.DeviceType, e.TransmissionType = uint16At(field, 0), field[2], field[3]
+	}
+	if e.Has(ExtendedRSSI) {
+		field, err := take(3)
+		if err != nil {
+			return nil, err
+		}
+		e.MeasurementType, e.RSSI, e.Threshold = field[0], int8(field[1]), int8(field[2])
+	}
+	if e.Has(ExtendedTimestamp) {
+		field, err := take(2)
+		if err != nil {
+			return nil, err
+		}
+		e.Timestamp = uint16At(field, 0)
+	}
+	return e, nil
+}
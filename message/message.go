@@ -0,0 +1,135 @@
+// Package message provides a Go struct for every message of the ant protocol.
+//
+// Each struct packs its own fields into an antpacket with Marshal and unpacks
+// them with Unmarshal, so multi-byte and bit fields are dealt with in one place.
+// Building packets from raw bytes with protocol.GenerateAntpacket remains
+// possible for anything these do not cover.
+package message
+
+import (
+	"encoding/binary"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// A Message is an ant message with its fields unpacked.
+type Message interface {
+	// ID returns the message id of the packet carrying the message.
+	ID() byte
+	// Marshal packs the message into an antpacket.
+	Marshal() (*protocol.Antpacket, error)
+	// Unmarshal unpacks pkt into the message.
+	Unmarshal(pkt *protocol.Antpacket) error
+}
+
+// messages makes an empty message for every message id of the catalog, apart
+// from those in directed.
+var messages = map[byte]func() Message{
+	protocol.UnassignChannel:             func() Message { return &UnassignChannel{} },
+	protocol.AssignChannel:               func() Message { return &AssignChannel{} },
+	protocol.SetChannelPeriod:            func() Message { return &SetChannelPeriod{} },
+	protocol.SetSearchTimeout:            func() Message { return &SetSearchTimeout{} },
+	protocol.SetChannelRFFrequency:       func() Message { return &SetChannelRFFrequency{} },
+	protocol.SetNetwork:                  func() Message { return &SetNetwork{} },
+	protocol.SetTransmitPower:            func() Message { return &SetTransmitPower{} },
+	protocol.IDListAdd:                   func() Message { return &IDListAdd{} },
+	protocol.IDListConfig:                func() Message { return &IDListConfig{} },
+	protocol.SetChannelTransmitPower:     func() Message { return &SetChannelTransmitPower{} },
+	protocol.SetLowPrioritySearchTimeout: func() Message { return &SetLowPrioritySearchTimeout{} },
+	protocol.SetSerialNumberSetChannelID: func() Message { return &SetSerialNumberSetChannelID{} },
+	protocol.EnableExtRXMesgs:            func() Message { return &EnableExtRXMesgs{} },
+	protocol.EnableLED:                   func() Message { return &EnableLED{} },
+	protocol.CrystalEnable:               func() Message { return &CrystalEnable{} },
+	protocol.LibConfig:                   func() Message { return &LibConfig{} },
+	protocol.FrequencyAgility:            func() Message { return &FrequencyAgility{} },
+	protocol.SetProximitySearch:          func() Message { return &SetProximitySearch{} },
+	protocol.SetChannelSearchPriority:    func() Message { return &SetChannelSearchPriority{} },
+	protocol.StartupMessage:              func() Message { return &StartupMessage{} },
+	protocol.SerialErrorMessage:          func() Message { return &SerialErrorMessage{} },
+	protocol.SystemReset:                 func() Message { return &SystemReset{} },
+	protocol.OpenChannel:                 func() Message { return &OpenChannel{} },
+	protocol.CloseChannel:                func() Message { return &CloseChannel{} },
+	protocol.OpenRxScanMode:              func() Message { return &OpenRxScanMode{} },
+	protocol.RequestMessage:              func() Message { return &RequestMessage{} },
+	protocol.SleepMessage:                func() Message { return &SleepMessage{} },
+	protocol.BroadcastData:               func() Message { return &BroadcastData{} },
+	protocol.AcknowledgeData:             func() Message { return &AcknowledgeData{} },
+	protocol.BurstTransferData:           func() Message { return &BurstTransferData{} },
+	protocol.ChannelResponseOrEvent:      func() Message { return &ChannelResponseOrEvent{} },
+	protocol.ChannelStatus:               func() Message { return &ChannelStatus{} },
+	protocol.ANTVersion:                  func() Message { return &ANTVersion{} },
+	protocol.Capabilities:                func() Message { return &Capabilities{} },
+	protocol.SerialNumber:                func() Message { return &SerialNumber{} },
+	protocol.CWInit:                      func() Message { return &CWInit{} },
+	protocol.CWTest:                      func() Message { return &CWTest{} },
+}
+
+// directed makes the messages of ids shared by a host command and a message
+// from the stick, by the direction they were sent in.
+var directed = map[protocol.Direction]map[byte]func() Message{
+	protocol.HostToANT: {
+		protocol.SetChannelID: func() Message { return &SetChannelID{} },
+	},
+	protocol.ANTToHost: {
+		protocol.ChannelID: func() Message { return &ChannelID{} },
+	},
+}
+
+// Decode unpacks pkt into the message struct for its id as sent in direction
+// dir, HostToANT for packets the host sends and ANTToHost for those it receives.
+func Decode(pkt *protocol.Antpacket, dir protocol.Direction) (Message, error) {
+	if _, ok := protocol.Lookup(pkt.ID, dir); !ok {
+		if _, known := protocol.MsgClasses[pkt.ID]; known {
+			return nil, protocol.ErrWrongDirection
+		}
+		return nil, protocol.ErrUnknownClass
+	}
+	newMessage, ok := directed[dir][pkt.ID]
+	if !ok {
+		newMessage, ok = messages[pkt.ID]
+	}
+	if !ok {
+		return nil, protocol.ErrUnknownClass
+	}
+	msg := newMessage()
+	err := msg.Unmarshal(pkt)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// check makes sure pkt is a message id with at least n data bytes, and no
+// more than the message's optional fields allow.
+func check(pkt *protocol.Antpacket, id byte, n int) error {
+	if pkt.ID != id {
+		return protocol.ErrUnexpectedMessage
+	}
+	if len(pkt.Data) < n {
+		return protocol.ErrMinimumPacketLength
+	}
+	if len(pkt.Data) > int(protocol.MsgClasses[id].Template.MaxLength) {
+		return protocol.ErrDataLength
+	}
+	return nil
+}
+
+// Little endian multi-byte fields
+
+func lsb(v uint16) byte {
+	return byte(v)
+}
+
+func msb(v uint16) byte {
+	return byte(v >> 8)
+}
+
+func uint16At(data []byte, i int) uint16 {
+	return binary.LittleEndian.Uint16(data[i:])
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+package message
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/protocol"
+	"reflect"
+	"testing"
+)
+
+func TestEveryClassHasAMessage(t *testing.T) {
+	for id := range protocol.MsgClasses {
+		for _, dir := range []protocol.Direction{protocol.HostToANT, protocol.ANTToHost} {
+			class, ok := protocol.Lookup(id, dir)
+			if !ok {
+				continue
+			}
+			newMessage, ok := directed[dir][id]
+			if !ok {
+				newMessage, ok = messages[id]
+			}
+			if !ok {
+				t.Errorf("No message for %s (0x%02X)", class.Name, id)
+				continue
+			}
+			pkt, err := newMessage().Marshal()
+			if err != nil {
+				t.Errorf("Error marshalling %s, %v", class.Name, err)
+				continue
+			}
+			if pkt.ID != id || pkt.MsgLen != class.Template.DataLength {
+				t.Errorf("%s marshalled to %v", class.Name, pkt)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	msgs := []Message{
+		&AssignChannel{1, 0x10, 2, 0},
+		&AssignChannel{1, 0x10, 2, 0x01},
+		&SetChannelPeriod{1, 8070},
+		&SetNetwork{1, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		&IDListAdd{2, 0xBEEF, 120, 1, 3},
+		&IDListConfig{2, 4, true},
+		&EnableExtRXMesgs{true},
+		&FrequencyAgility{3, [3]byte{3, 39, 75}},
+		&RequestMessage{1, protocol.ChannelStatus},
+		&BurstTransferData{5, 6, [8]byte{8, 7, 6, 5, 4, 3, 2, 1}, nil},
+		&BroadcastData{2, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{0x20, 0x34, 0x12}},
+		&ChannelResponseOrEvent{1, 0x01, protocol.EventChannelClosed},
+		&ChannelStatus{1, 3, 2, 4},
+		&SetChannelID{1, 0x1234, 120, 1},
+		&ChannelID{1, 0x1234, 120, 1},
+		&ANTVersion{"AJK1.04RAF"},
+		&ANTVersion{"AP2USB1.05BCE"},
+		&Capabilities{8, 3, 0, 0xBA, 0x36, 0, 0, 0},
+		&Capabilities{8, 3, 0, 0xBA, 0x36, 0, 0x01, 0x02},
+		&SerialNumber{0x12345678},
+		&SerialErrorMessage{protocol.SerialErrorChecksum, []byte{0xA4, 0x01, 0x4B, 0x01, 0xEF}},
+		&CWTest{3, 57},
+	}
+	for _, msg := range msgs {
+		pkt, err := msg.Marshal()
+		if err != nil {
+			t.Fatalf("Error marshalling %#v, %v", msg, err)
+		}
+		// Messages sent both ways decode the same in each
+		matched := false
+		for _, dir := range []protocol.Direction{protocol.HostToANT, protocol.ANTToHost} {
+			decoded, err := Decode(pkt, dir)
+			if err == protocol.ErrWrongDirection {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Error decoding %v, %v", pkt, err)
+			}
+			matched = matched || reflect.DeepEqual(decoded, msg)
+		}
+		if !matched {
+			t.Fatalf("Round trip of %#v did not decode to it", msg)
+		}
+	}
+}
+
+func TestDecodeDirection(t *testing.T) {
+	pkt, _ := (&SetChannelID{1, 0x1234, 120, 1}).Marshal()
+	sent, err := Decode(pkt, protocol.HostToANT)
+	if _, ok := sent.(*SetChannelID); !ok || err != nil {
+		t.Fatal("Sent 0x51 not decoded as Set Channel ID, ", sent, err)
+	}
+	received, err := Decode(pkt, protocol.ANTToHost)
+	if _, ok := received.(*ChannelID); !ok || err != nil {
+		t.Fatal("Received 0x51 not decoded as Channel ID, ", received, err)
+	}
+
+	pkt, _ = (&StartupMessage{}).Marshal()
+	if _, err = Decode(pkt, protocol.HostToANT); err != protocol.ErrWrongDirection {
+		t.Fatal("Decoded a startup message sent by the host, ", err)
+	}
+}
+
+func TestExtendedData(t *testing.T) {
+	ext, err := DecodeExtended(nil)
+	if ext != nil || err != nil {
+		t.Fatal("Decoded extended data from a standard message, ", ext, err)
+	}
+
+	// Fields follow the flag byte in a fixed order
+	data := []byte{0xE0, 0x34, 0x12, 120, 0x01, 0x20, 0xC4, 0xA6, 0x00, 0x40}
+	ext, err = DecodeExtended(data)
+	if err != nil {
+		t.Fatal("Error decoding extended data, ", err)
+	}
+	expected := &ExtendedData{0xE0, 0x1234, 120, 1, 0x20, -60, -90, 0x4000}
+	if !reflect.DeepEqual(ext, expected) {
+		t.Fatalf("Decoded %#v", ext)
+	}
+	if !bytes.Equal(ext.Bytes(), data) {
+		t.Fatalf("Packed to % X", ext.Bytes())
+	}
+
+	ext, err = DecodeExtended([]byte{0x20, 0x00, 0x40})
+	if err != nil || ext.Has(ExtendedChannelID) || ext.Timestamp != 0x4000 {
+		t.Fatal("Error decoding timestamp alone, ", ext, err)
+	}
+	if _, err = DecodeExtended([]byte{0x80, 0x34, 0x12}); err != protocol.ErrDataLength {
+		t.Fatal("Decoded a truncated channel id, ", err)
+	}
+}
+
+func TestSplitBurst(t *testing.T) {
+	data := make([]byte, 17)
+	data[16] = 0xAA
+	packets := SplitBurst(2, data)
+	if len(packets) != 3 {
+		t.Fatal("Split into ", len(packets), " packets")
+	}
+	for i, seq := range []byte{0, 1, 2 | BurstLast} {
+		if packets[i].Channel != 2 || packets[i].Sequence != seq {
+			t.Fatalf("Packet %d is %#v", i, packets[i])
+		}
+	}
+	if packets[2].Data != [8]byte{0xAA} {
+		t.Fatalf("Last packet not padded, % X", packets[2].Data)
+	}
+	if seq := BurstSequence(4, 6); seq != 1 {
+		t.Fatal("Sequence did not wrap to 1, ", seq)
+	}
+	if seq := BurstSequence(0, 1); seq != BurstLast {
+		t.Fatal("Single packet burst sequence ", seq)
+	}
+}
+
+func TestFieldPacking(t *testing.T) {
+	check := func(msg Message, data ...byte) {
+		pkt, err := msg.Marshal()
+		if err != nil {
+			t.Fatal("Error marshalling, ", err)
+		}
+		if !bytes.Equal(pkt.Data, data) {
+			t.Fatalf("%#v packed to % X, expected % X", msg, pkt.Data, data)
+		}
+	}
+	check(&SetChannelPeriod{1, 8070}, 0x01, 0x86, 0x1F)
+	check(&SetChannelID{1, 0x1234, 120, 1}, 0x01, 0x34, 0x12, 120, 0x01)
+	check(&ChannelStatus{1, 2, 1, 0}, 0x01, 0x06)
+	check(&BurstTransferData{3, 2, [8]byte{}, nil}, 0x43, 0, 0, 0, 0, 0, 0, 0, 0)
+	check(&AssignChannel{1, 0x00, 1, 0}, 0x01, 0x00, 0x01)
+	check(&AssignChannel{1, 0x00, 1, 0x01}, 0x01, 0x00, 0x01, 0x01)
+	check(&ANTVersion{"AJK1.04RAF"}, 'A', 'J', 'K', '1', '.', '0', '4', 'R', 'A', 'F', 0)
+	check(&SerialNumber{0x12345678}, 0x78, 0x56, 0x34, 0x12)
+	check(&SetTransmitPower{3}, 0x00, 0x03)
+	check(&Capabilities{MaxChannels: 8, MaxNetworks: 3}, 8, 3, 0, 0)
+	check(&Capabilities{MaxChannels: 8, MaxNetworks: 3, AdvancedOptions2: 0x36}, 8, 3, 0, 0, 0x36, 0)
+
+	pkt, _ := (&OpenChannel{1}).Marshal()
+	if err := (&CloseChannel{}).Unmarshal(pkt); err != protocol.ErrUnexpectedMessage {
+		t.Fatal("Unmarshalled a packet of the wrong class, ", err)
+	}
+	short := &protocol.Antpacket{Sync: protocol.SyncByte, MsgLen: 4, ID: protocol.Capabilities, Data: []byte{8, 3, 0, 0xBA}}
+	var c Capabilities
+	if err := c.Unmarshal(short); err != nil || c.MaxChannels != 8 || c.AdvancedOptions2 != 0 {
+		t.Fatal("Error unmarshalling short capabilities, ", c, err)
+	}
+
+	long := &protocol.Antpacket{Sync: protocol.SyncByte, MsgLen: 9, ID: protocol.Capabilities, Data: make([]byte, 9)}
+	if err := c.Unmarshal(long); err != protocol.ErrDataLength {
+		t.Fatal("Unmarshalled capabilities longer than their optional fields, ", err)
+	}
+}
@@ -0,0 +1,215 @@
+package message
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/Fumon/go-ant/protocol"
+)
+
+// StartupMessage is sent by the stick whenever it starts.
+type StartupMessage struct {
+	Reason protocol.StartupReason
+}
+
+func (m *StartupMessage) ID() byte { return protocol.StartupMessage }
+
+func (m *StartupMessage) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), byte(m.Reason))
+}
+
+func (m *StartupMessage) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	m.Reason = protocol.StartupReason(pkt.Data[0])
+	return nil
+}
+
+// SerialErrorMessage is sent by the stick when it could not read a message.
+// Message is whatever part of the offending message the stick echoed back.
+type SerialErrorMessage struct {
+	Code    protocol.SerialErrorCode
+	Message []byte
+}
+
+func (m *SerialErrorMessage) ID() byte { return protocol.SerialErrorMessage }
+
+func (m *SerialErrorMessage) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), append([]byte{byte(m.Code)}, m.Message...)...)
+}
+
+func (m *SerialErrorMessage) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	m.Code = protocol.SerialErrorCode(pkt.Data[0])
+	m.Message = nil
+	if len(pkt.Data) > 1 {
+		m.Message = append([]byte(nil), pkt.Data[1:]...)
+	}
+	return nil
+}
+
+// ChannelResponseOrEvent is a response to a command, or an event on a channel
+// when MessageID is 0x01.
+type ChannelResponseOrEvent struct {
+	Channel   byte
+	MessageID byte
+	Code      protocol.ResponseCode
+}
+
+func (m *ChannelResponseOrEvent) ID() byte { return protocol.ChannelResponseOrEvent }
+
+func (m *ChannelResponseOrEvent) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, m.MessageID, byte(m.Code))
+}
+
+func (m *ChannelResponseOrEvent) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 3); err != nil {
+		return err
+	}
+	m.Channel, m.MessageID, m.Code = pkt.Data[0], pkt.Data[1], protocol.ResponseCode(pkt.Data[2])
+	return nil
+}
+
+// ChannelStatus reports the state of a channel. The status byte packs the
+// state in bits 0-1, the network in bits 2-3 and the channel type in bits 4-7.
+type ChannelStatus struct {
+	Channel     byte
+	State       byte
+	Network     byte
+	ChannelType byte
+}
+
+func (m *ChannelStatus) ID() byte { return protocol.ChannelStatus }
+
+func (m *ChannelStatus) Marshal() (*protocol.Antpacket, error) {
+	status := m.State&0x03 | (m.Network&0x03)<<2 | m.ChannelType<<4
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, status)
+}
+
+func (m *ChannelStatus) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 2); err != nil {
+		return err
+	}
+	status := pkt.Data[1]
+	m.Channel, m.State, m.Network, m.ChannelType = pkt.Data[0], status&0x03, (status>>2)&0x03, status>>4
+	return nil
+}
+
+// ChannelID reports the id of the device a channel talks to.
+type ChannelID struct {
+	Channel          byte
+	DeviceNumber     uint16
+	DeviceType       byte
+	TransmissionType byte
+}
+
+func (m *ChannelID) ID() byte { return protocol.ChannelID }
+
+func (m *ChannelID) Marshal() (*protocol.Antpacket, error) {
+	return protocol.GenerateAntpacket(m.ID(), m.Channel, lsb(m.DeviceNumber), msb(m.DeviceNumber), m.DeviceType, m.TransmissionType)
+}
+
+func (m *ChannelID) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 5); err != nil {
+		return err
+	}
+	m.Channel, m.DeviceNumber, m.DeviceType, m.TransmissionType = pkt.Data[0], uint16At(pkt.Data, 1), pkt.Data[3], pkt.Data[4]
+	return nil
+}
+
+// ANTVersion is the stick's firmware version.
+type ANTVersion struct {
+	Version string
+}
+
+func (m *ANTVersion) ID() byte { return protocol.ANTVersion }
+
+// Marshal NUL terminates the version, padding it to the 11 byte minimum and
+// truncating it to the longest message if need be.
+func (m *ANTVersion) Marshal() (*protocol.Antpacket, error) {
+	class := protocol.MsgClasses[m.ID()]
+	n := len(m.Version) + 1
+	if n < int(class.Template.DataLength) {
+		n = int(class.Template.DataLength)
+	}
+	if n > int(class.Template.MaxLength) {
+		n = int(class.Template.MaxLength)
+	}
+	version := make([]byte, n)
+	copy(version[:n-1], m.Version)
+	return protocol.GenerateAntpacket(m.ID(), version...)
+}
+
+func (m *ANTVersion) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 1); err != nil {
+		return err
+	}
+	version := pkt.Data
+	if i := bytes.IndexByte(version, 0); i >= 0 {
+		version = version[:i]
+	}
+	m.Version = string(version)
+	return nil
+}
+
+// Capabilities reports the limits and options of the stick.
+type Capabilities struct {
+	MaxChannels          byte
+	MaxNetworks          byte
+	StandardOptions      byte
+	AdvancedOptions      byte
+	AdvancedOptions2     byte
+	MaxSensRcoreChannels byte
+	AdvancedOptions3     byte
+	AdvancedOptions4     byte
+}
+
+func (m *Capabilities) ID() byte { return protocol.Capabilities }
+
+// Marshal leaves off the pairs of optional fields added by newer firmware
+// while they are zero, as older firmware would.
+func (m *Capabilities) Marshal() (*protocol.Antpacket, error) {
+	args := []byte{m.MaxChannels, m.MaxNetworks, m.StandardOptions, m.AdvancedOptions,
+		m.AdvancedOptions2, m.MaxSensRcoreChannels, m.AdvancedOptions3, m.AdvancedOptions4}
+	for len(args) > 4 && args[len(args)-1] == 0 && args[len(args)-2] == 0 {
+		args = args[:len(args)-2]
+	}
+	return protocol.GenerateAntpacket(m.ID(), args...)
+}
+
+// Unmarshal accepts the 4 byte reply of older firmware and the 8 byte reply of
+// newer, leaving any options not sent zero.
+func (m *Capabilities) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 4); err != nil {
+		return err
+	}
+	data := make([]byte, 8)
+	copy(data, pkt.Data)
+	m.MaxChannels, m.MaxNetworks, m.StandardOptions = data[0], data[1], data[2]
+	m.AdvancedOptions, m.AdvancedOptions2, m.MaxSensRcoreChannels = data[3], data[4], data[5]
+	m.AdvancedOptions3, m.AdvancedOptions4 = data[6], data[7]
+	return nil
+}
+
+// SerialNumber is the stick's serial number.
+type SerialNumber struct {
+	SerialNumber uint32
+}
+
+func (m *SerialNumber) ID() byte { return protocol.SerialNumber }
+
+func (m *SerialNumber) Marshal() (*protocol.Antpacket, error) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, m.SerialNumber)
+	return protocol.GenerateAntpacket(m.ID(), data...)
+}
+
+func (m *SerialNumber) Unmarshal(pkt *protocol.Antpacket) error {
+	if err := check(pkt, m.ID(), 4); err != nil {
+		return err
+	}
+	m.SerialNumber = binary.LittleEndian.Uint32(pkt.Data)
+	return nil
+}
@@ -0,0 +1,78 @@
+package protocol
+
+import (
+	"sync/atomic"
+)
+
+// MaxMsgLength is the largest msglen the framer will accept. Anything above this
+// cannot fit in a single read from the stick and must be a corrupt length byte.
+const MaxMsgLength = MaxDataLength - 4
+
+// A Framer reassembles antpackets from a stream of bytes.
+//
+// Reads from the stick may hold several packets, part of a packet, or garbage
+// left over from line noise. Bytes are written to the framer as they arrive and
+// complete packets are taken out with Next. When a candidate packet fails
+// validation the framer drops its sync byte and rescans for the next one.
+type Framer struct {
+	buf       []byte
+	discarded uint64
+}
+
+// Write appends bytes received from the stick. It never fails.
+func (f *Framer) Write(p []byte) (int, error) {
+	f.buf = append(f.buf, p...)
+	return len(p), nil
+}
+
+// Next returns the next complete and valid packet, or nil if more bytes are needed.
+func (f *Framer) Next() *Antpacket {
+	for {
+		// Scan for sync
+		skip := 0
+		for skip < len(f.buf) && f.buf[skip] != SyncByte {
+			skip++
+		}
+		f.discard(skip)
+
+		if len(f.buf) < 2 {
+			return nil
+		}
+
+		msglen := int(f.buf[1])
+		if msglen > MaxMsgLength {
+			// Not a real packet, resync after this sync byte
+			f.discard(1)
+			continue
+		}
+
+		// Sync, length, id, data, checksum
+		pktlen := msglen + 4
+		if len(f.buf) < pktlen {
+			return nil
+		}
+
+		pkt, err := ReadAntpacket(f.buf[:pktlen])
+		if err != nil {
+			f.discard(1)
+			continue
+		}
+
+		f.buf = f.buf[pktlen:]
+		return pkt
+	}
+}
+
+// discard drops n bytes from the front of the buffer and counts them.
+func (f *Framer) discard(n int) {
+	if n == 0 {
+		return
+	}
+	f.buf = f.buf[n:]
+	atomic.AddUint64(&f.discarded, uint64(n))
+}
+
+// DiscardedBytes returns the number of bytes thrown away while resynchronising.
+func (f *Framer) DiscardedBytes() uint64 {
+	return atomic.LoadUint64(&f.discarded)
+}
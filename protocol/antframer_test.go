@@ -0,0 +1,79 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+// encodedPacket generates a packet in line format.
+func encodedPacket(class byte, args ...byte) []byte {
+	pkt, _ := GenerateAntpacket(class, args...)
+	buf := new(bytes.Buffer)
+	pkt.ToBinary(buf)
+	return buf.Bytes()
+}
+
+func TestFramerSplitPacket(t *testing.T) {
+	frame := encodedPacket(ChannelResponseOrEvent, 1, OpenChannel, 0)
+	f := &Framer{}
+
+	f.Write(frame[:3])
+	if pkt := f.Next(); pkt != nil {
+		t.Fatal("Framer returned a packet from a partial frame")
+	}
+
+	f.Write(frame[3:])
+	pkt := f.Next()
+	if pkt == nil || pkt.ID != ChannelResponseOrEvent {
+		t.Fatal("Framer failed to reassemble split packet, got ", pkt)
+	}
+	if f.DiscardedBytes() != 0 {
+		t.Fatal("Framer discarded bytes of a valid stream")
+	}
+}
+
+func TestFramerCoalescedPackets(t *testing.T) {
+	f := &Framer{}
+	f.Write(append(encodedPacket(StartupMessage, 0x20), encodedPacket(BroadcastData, 1, 2, 3, 4, 5, 6, 7, 8, 9)...))
+
+	for _, id := range []byte{StartupMessage, BroadcastData} {
+		pkt := f.Next()
+		if pkt == nil || pkt.ID != id {
+			t.Fatalf("Expected packet %X, got %v", id, pkt)
+		}
+	}
+	if pkt := f.Next(); pkt != nil {
+		t.Fatal("Framer returned an extra packet, ", pkt)
+	}
+}
+
+func TestFramerResync(t *testing.T) {
+	f := &Framer{}
+
+	// Garbage, then a packet with a corrupt checksum, then a valid packet
+	corrupt := encodedPacket(StartupMessage, 0x20)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	stream := []byte{0x00, 0x13, 0x37}
+	stream = append(stream, corrupt...)
+	stream = append(stream, encodedPacket(SerialErrorMessage, 0x02)...)
+	f.Write(stream)
+
+	pkt := f.Next()
+	if pkt == nil || pkt.ID != SerialErrorMessage {
+		t.Fatal("Framer failed to resynchronise, got ", pkt)
+	}
+	if f.DiscardedBytes() != uint64(3+len(corrupt)) {
+		t.Fatalf("Discarded %d bytes, expected %d", f.DiscardedBytes(), 3+len(corrupt))
+	}
+}
+
+func TestFramerBadLength(t *testing.T) {
+	f := &Framer{}
+	f.Write([]byte{SyncByte, 0xFF})
+	f.Write(encodedPacket(StartupMessage, 0x20))
+
+	pkt := f.Next()
+	if pkt == nil || pkt.ID != StartupMessage {
+		t.Fatal("Framer failed to skip oversized length, got ", pkt)
+	}
+}
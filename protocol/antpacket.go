@@ -0,0 +1,218 @@
+// Package protocol is a marshallable datastructure for constructing and decoding ant packets,
+// along with the catalog of ant message classes and channel response codes.
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	MaxDataLength = 56
+	SyncByte      = 0xA4
+)
+
+// AntpacketTemplate describes the shape of a message class.
+// DataLength bytes of data are required, and optional trailing fields may take
+// a packet up to MaxLength.
+type AntpacketTemplate struct {
+	DataLength byte
+	MaxLength  byte
+	ID         byte
+}
+
+// Fits reports whether n bytes of data is a valid length for the template.
+func (t AntpacketTemplate) Fits(n int) bool {
+	return n >= int(t.DataLength) && n <= int(t.MaxLength)
+}
+
+// Antpacket encapsulates the basic structure of a standard ant packet.
+//
+// Byte #	-	Label
+// 0	-	Sync
+// 1	-	Message Length
+// 2	- Message ID
+// 3-(Message Length+2) - Data bytes (LSB ORDERING!)
+// (Message Length + 3) - Checksum (XOR of all previous bytes including SYNC)
+type Antpacket struct {
+	Sync     byte
+	MsgLen   byte
+	ID       byte
+	Data     []byte
+	Checksum byte
+}
+
+// GenerateAntpacket builds a checksummed packet of the given message class from its data bytes.
+func GenerateAntpacket(class byte, args ...byte) (*Antpacket, error) {
+	if args == nil {
+		return nil, ErrArgumentsNil
+	}
+
+	v, ok := MsgClasses[class]
+	if ok == false {
+		return nil, ErrUnknownClass
+	}
+
+	if !v.Template.Fits(len(args)) {
+		return nil, ErrArgumentsLen
+	}
+
+	pkt := &Antpacket{
+		SyncByte,
+		byte(len(args)),
+		v.Template.ID,
+		make([]byte, len(args)),
+		0,
+	}
+
+	for i, x := range args {
+		pkt.Data[i] = x
+	}
+
+	pkt.SetChecksum()
+
+	return pkt, nil
+}
+
+// Stringify the packet with field explainations.
+// Ids shared by host and stick messages are named for both; see Describe.
+func (a *Antpacket) String() string {
+	return a.describe(MsgClasses[a.ID])
+}
+
+// Describe stringifies the packet as a message sent in direction dir.
+// Data bytes are shown raw against their fields; the types in package message
+// decode bitfields and multi-byte values.
+func (a *Antpacket) Describe(dir Direction) string {
+	c, ok := Lookup(a.ID, dir)
+	if !ok {
+		return a.String()
+	}
+	return a.describe(c)
+}
+
+func (a *Antpacket) describe(c *MsgClass) string {
+	if c == nil {
+		c = &MsgClass{}
+	}
+
+	head := fmt.Sprint(
+		"\"", c.Name, "\" - ",
+		"Class: ", c.Class, "\n\tData: ",
+	)
+
+	data := ""
+
+	for i, x := range c.DataFieldDesc {
+		if i >= len(a.Data) {
+			// Optional fields which were left out
+			break
+		}
+		data = fmt.Sprintf("%s%s - %X, ",
+			data,
+			x,
+			a.Data[i],
+		)
+	}
+
+	return fmt.Sprintln(head, data)
+}
+
+// Channel returns the channel number the packet belongs to, if it belongs to one.
+func (a *Antpacket) Channel() (byte, bool) {
+	if a.ID == BurstTransferData && len(a.Data) > 0 {
+		// Upper bits hold the sequence number
+		return a.Data[0] & 0x1F, true
+	}
+	c, ok := MsgClasses[a.ID]
+	if !ok || !c.HasChannel() || len(a.Data) == 0 {
+		return 0, false
+	}
+	return a.Data[0], true
+}
+
+// ValidateLength checks the data length against the message class, allowing
+// for optional trailing fields. Unknown classes are not checked.
+func (a *Antpacket) ValidateLength() error {
+	if int(a.MsgLen) != len(a.Data) {
+		return ErrDataLength
+	}
+	c, ok := MsgClasses[a.ID]
+	if ok && !c.Template.Fits(len(a.Data)) {
+		return ErrDataLength
+	}
+	return nil
+}
+
+// Checksum the packet
+func (a *Antpacket) GenChecksum() (chk byte) {
+	// XOR everything
+	chk = a.Sync ^ a.MsgLen ^ a.ID
+	for _, e := range a.Data {
+		chk = chk ^ e
+	}
+	return
+}
+
+// Set the checksum for a constructed packet
+func (a *Antpacket) SetChecksum() {
+	a.Checksum = a.GenChecksum()
+}
+
+// Validate a packet by checksum
+// Returns true if valid
+func (a *Antpacket) ValidateChecksum() bool {
+	if a.GenChecksum() == a.Checksum {
+		return true
+	}
+	return false
+}
+
+// Encode to line format
+func (a *Antpacket) ToBinary(buffer *bytes.Buffer) (length int, err error) {
+	// TODO: more elegant than this
+	binary.Write(buffer, binary.LittleEndian, a.Sync)
+	binary.Write(buffer, binary.LittleEndian, a.MsgLen)
+	binary.Write(buffer, binary.LittleEndian, a.ID)
+	binary.Write(buffer, binary.LittleEndian, a.Data)
+	binary.Write(buffer, binary.LittleEndian, a.Checksum)
+
+	length = buffer.Len()
+	return
+}
+
+// Unpack from line format
+func ReadAntpacket(buf []byte) (*Antpacket, error) {
+	// Minimum Length check
+	if len(buf) < 5 {
+		return nil, ErrMinimumPacketLength
+	}
+
+	ret := &Antpacket{}
+	stream := bytes.NewReader(buf)
+
+	ret.Sync, _ = stream.ReadByte()
+	if ret.Sync != SyncByte {
+		return nil, ErrMissingSync
+	}
+	ret.MsgLen, _ = stream.ReadByte()
+	if len(buf) < int(ret.MsgLen)+4 {
+		return nil, ErrPacketTruncated
+	}
+	ret.ID, _ = stream.ReadByte()
+	data := make([]byte, ret.MsgLen)
+	_, err := stream.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	ret.Data = data
+	ret.Checksum, _ = stream.ReadByte()
+
+	// Verify checksum
+	if ret.GenChecksum() != ret.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,173 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateAntpacketNoArgs(t *testing.T) {
+	_, err := GenerateAntpacket(SystemReset)
+	if err != ErrArgumentsNil {
+		t.Fail()
+	}
+}
+
+func TestGenerateAntpacketArgLength(t *testing.T) {
+	// Too many
+	_, err := GenerateAntpacket(SystemReset, 0, 1)
+	if err != ErrArgumentsLen {
+		t.Fail()
+	}
+	// Too few
+	_, err = GenerateAntpacket(SetNetwork, 2, 3)
+	if err != ErrArgumentsLen {
+		t.Fail()
+	}
+}
+
+func TestGenerateAntpacketOptionalFields(t *testing.T) {
+	// Extended assignment is optional
+	pkt, err := GenerateAntpacket(AssignChannel, 0, 0, 1, 0x01)
+	if err != nil || pkt.MsgLen != 4 {
+		t.Fatal("Error generating with optional field, ", err)
+	}
+	if err = pkt.ValidateLength(); err != nil {
+		t.Fatal("Optional field failed validation, ", err)
+	}
+	// But there is no more than one
+	_, err = GenerateAntpacket(AssignChannel, 0, 0, 1, 0x01, 0)
+	if err != ErrArgumentsLen {
+		t.Fail()
+	}
+
+	pkt = &Antpacket{SyncByte, 2, AssignChannel, []byte{0, 0}, 0}
+	if pkt.ValidateLength() != ErrDataLength {
+		t.Fatal("Short packet passed validation")
+	}
+	pkt = &Antpacket{SyncByte, 3, AssignChannel, []byte{0, 0}, 0}
+	if pkt.ValidateLength() != ErrDataLength {
+		t.Fatal("Packet with a bad length byte passed validation")
+	}
+}
+
+func TestGenerateAntpacketUnknownClass(t *testing.T) {
+	_, err := GenerateAntpacket(0xFF, 0)
+	if err != ErrUnknownClass {
+		t.Fail()
+	}
+}
+
+func TestCalculateChecksumZero(t *testing.T) {
+	// Create a packet with only 0s
+	pkt := &Antpacket{}
+	if pkt.GenChecksum() != byte(0) {
+		t.Fail()
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	// Create deliberately wrong checksum
+	pkt := &Antpacket{}
+	pkt.Checksum = 7
+
+	if pkt.ValidateChecksum() != false {
+		t.Fail()
+	}
+}
+
+func TestToBinary(t *testing.T) {
+	pkt := &Antpacket{}
+	buf := new(bytes.Buffer)
+
+	_, err := pkt.ToBinary(buf)
+	if err != nil {
+		t.Fatal("Function returned error, ", err)
+	}
+
+	byteslice := buf.Bytes()
+	for _, x := range byteslice {
+		if x != 0 {
+			t.Fail()
+		}
+	}
+}
+
+func TestReadValues(t *testing.T) {
+	testPkt := &Antpacket{
+		SyncByte,
+		0x4,
+		0x32,
+		[]byte{1, 2, 3, 4},
+		0,
+	}
+	testPkt.SetChecksum()
+
+	buf := new(bytes.Buffer)
+	testPkt.ToBinary(buf)
+
+	readPacket, err := ReadAntpacket(buf.Bytes())
+	if err != nil {
+		t.Fatal("Error reading packet, ", err)
+	}
+
+	if readPacket.Sync != SyncByte || readPacket.MsgLen != 0x4 || readPacket.ID != 0x32 {
+		t.Fail()
+	}
+
+	if len(readPacket.Data) != len(testPkt.Data) {
+		t.Fail()
+	}
+
+	for i, x := range readPacket.Data {
+		if testPkt.Data[i] != x {
+			t.Fail()
+		}
+	}
+
+	if readPacket.Checksum != testPkt.Checksum {
+		t.Fail()
+	}
+}
+
+func TestReadChecksumValidate(t *testing.T) {
+	// Sane packet but incorrect checksum
+	testPkt := &Antpacket{
+		SyncByte,
+		0x4,
+		0x32,
+		[]byte{1, 2, 3, 4},
+		0,
+	}
+	buf := new(bytes.Buffer)
+	testPkt.ToBinary(buf)
+
+	_, err := ReadAntpacket(buf.Bytes())
+
+	if err != ErrChecksumMismatch {
+		t.Fatal("Failed to reject incorrect checksum")
+	}
+}
+
+func TestReadLength(t *testing.T) {
+	buf := make([]byte, 3)
+	_, err := ReadAntpacket(buf)
+	if err != ErrMinimumPacketLength {
+		t.Fail()
+	}
+}
+
+func TestReadMissingSync(t *testing.T) {
+	buf := []byte{0x00, 0x01, SystemReset, 0x00, 0x00}
+	_, err := ReadAntpacket(buf)
+	if err != ErrMissingSync {
+		t.Fail()
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	buf := []byte{SyncByte, 0x04, 0x32, 0x01, 0x02}
+	_, err := ReadAntpacket(buf)
+	if err != ErrPacketTruncated {
+		t.Fail()
+	}
+}
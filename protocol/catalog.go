@@ -0,0 +1,623 @@
+// Code generated by msggen from message_types.csv. DO NOT EDIT.
+
+package protocol
+
+// Config Messages, HOST -> ANT
+const (
+	UnassignChannel             = 0x41
+	AssignChannel               = 0x42
+	SetChannelID                = 0x51
+	SetChannelPeriod            = 0x43
+	SetSearchTimeout            = 0x44
+	SetChannelRFFrequency       = 0x45
+	SetNetwork                  = 0x46
+	SetTransmitPower            = 0x47
+	IDListAdd                   = 0x59
+	IDListConfig                = 0x5A
+	SetChannelTransmitPower     = 0x60
+	SetLowPrioritySearchTimeout = 0x63
+	SetSerialNumberSetChannelID = 0x65
+	EnableExtRXMesgs            = 0x66
+	EnableLED                   = 0x68
+	CrystalEnable               = 0x6D
+	LibConfig                   = 0x6E
+	FrequencyAgility            = 0x70
+	SetProximitySearch          = 0x71
+	SetChannelSearchPriority    = 0x75
+)
+
+// Notifications ANT -> HOST
+const (
+	StartupMessage     = 0x6F
+	SerialErrorMessage = 0xAE
+)
+
+// Control Messages HOST->ANT
+const (
+	SystemReset    = 0x4A
+	OpenChannel    = 0x4B
+	CloseChannel   = 0x4C
+	OpenRxScanMode = 0x5B
+	RequestMessage = 0x4D
+	SleepMessage   = 0xC5
+)
+
+// Data Messages HOST<->ANT
+const (
+	BroadcastData     = 0x4E
+	AcknowledgeData   = 0x4F
+	BurstTransferData = 0x50
+)
+
+// Channel/Event Messages
+const (
+	ChannelResponseOrEvent = 0x40
+)
+
+// Requested Response ANT->HOST
+const (
+	ChannelStatus = 0x52
+	ChannelID     = 0x51
+	ANTVersion    = 0x3E
+	Capabilities  = 0x54
+	SerialNumber  = 0x61
+)
+
+// Test Mode HOST->ANT
+const (
+	CWInit = 0x53
+	CWTest = 0x48
+)
+
+// MsgClasses is the catalog of every message class, keyed by message id.
+// Ids shared by a host command and a message from the stick have one merged entry.
+var MsgClasses = map[byte]*MsgClass{
+	UnassignChannel: {
+		Name:      "Unassign Channel",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x41},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.1",
+		DataFieldDesc: []string{
+			"Channel Number",
+		},
+	},
+	AssignChannel: {
+		Name:      "Assign Channel",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 4, ID: 0x42},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.2",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Channel Type",
+			"Network Number",
+			"[Extended Assign’t]",
+		},
+	},
+	SetChannelID: {
+		Name:      "Set Channel ID / Channel ID",
+		Class:     "Config / Requested Response",
+		Template:  AntpacketTemplate{DataLength: 5, MaxLength: 5, ID: 0x51},
+		Direction: Bidirectional,
+		Reply:     true,
+		Section:   "9.5.2.3 / 9.5.7.2",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Device number(1/2)",
+			"Device number(2/2)",
+			"Device Type ID",
+			"Trans. Type / Man ID",
+		},
+	},
+	SetChannelPeriod: {
+		Name:      "Set Channel Period",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 3, ID: 0x43},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.4",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Messaging Period(1/2)",
+			"Messaging Period(2/2)",
+		},
+	},
+	SetSearchTimeout: {
+		Name:      "Set Search Timeout",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x44},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.5",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Search Timeout",
+		},
+	},
+	SetChannelRFFrequency: {
+		Name:      "Set Channel RF Frequency",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x45},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.6",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"RF Frequency",
+		},
+	},
+	SetNetwork: {
+		Name:      "Set Network",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 9, MaxLength: 9, ID: 0x46},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.7",
+		DataFieldDesc: []string{
+			"Net #",
+			"Key 0",
+			"Key 1",
+			"Key 2",
+			"Key 3",
+			"Key 4",
+			"Key 5",
+			"Key 6",
+			"Key 7",
+		},
+	},
+	SetTransmitPower: {
+		Name:      "Set Transmit Power",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x47},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.8",
+		DataFieldDesc: []string{
+			"0",
+			"TX Power",
+		},
+	},
+	IDListAdd: {
+		Name:      "ID List Add",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 6, MaxLength: 6, ID: 0x59},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.9",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Device number(1/2)",
+			"Device number(2/2)",
+			"Device Type ID",
+			"Trans. Type",
+			"List Index",
+		},
+	},
+	IDListConfig: {
+		Name:      "ID List Config",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 3, ID: 0x5A},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.10",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"List Size",
+			"Exclude",
+		},
+	},
+	SetChannelTransmitPower: {
+		Name:      "Set Channel Transmit Power",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x60},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.11",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"TX Power",
+		},
+	},
+	SetLowPrioritySearchTimeout: {
+		Name:      "Set Low Priority Search Timeout",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x63},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.12",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Search Timeout",
+		},
+	},
+	SetSerialNumberSetChannelID: {
+		Name:      "Set Serial Number Set Channel ID",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 3, ID: 0x65},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.13",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Device Type ID",
+			"Trans. Type",
+		},
+	},
+	EnableExtRXMesgs: {
+		Name:      "Enable Ext RX Mesgs",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x66},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.14",
+		DataFieldDesc: []string{
+			"0",
+			"Enable",
+		},
+	},
+	EnableLED: {
+		Name:      "Enable LED",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x68},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.15",
+		DataFieldDesc: []string{
+			"0",
+			"Enable",
+		},
+	},
+	CrystalEnable: {
+		Name:      "Crystal Enable",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x6D},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.16",
+		DataFieldDesc: []string{
+			"0",
+		},
+	},
+	LibConfig: {
+		Name:      "Lib Config",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x6E},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.17",
+		DataFieldDesc: []string{
+			"0",
+			"Lib Config",
+		},
+	},
+	FrequencyAgility: {
+		Name:      "Frequency Agility",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 4, MaxLength: 4, ID: 0x70},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.18",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Freq’ 1",
+			"Freq’ 2",
+			"Freq’ 3",
+		},
+	},
+	SetProximitySearch: {
+		Name:      "Set Proximity Search",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x71},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.19",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Search Threshold",
+		},
+	},
+	SetChannelSearchPriority: {
+		Name:      "Set Channel Search Priority",
+		Class:     "Config",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x75},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.2.20",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Search Priority",
+		},
+	},
+	StartupMessage: {
+		Name:      "Startup Message",
+		Class:     "Notifications",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x6F},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.3.1",
+		DataFieldDesc: []string{
+			"Startup Message",
+		},
+	},
+	SerialErrorMessage: {
+		Name:      "Serial Error Message",
+		Class:     "Notifications",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 52, ID: 0xAE},
+		Direction: ANTToHost,
+		Reply:     true,
+		Section:   "9.5.3.2",
+		DataFieldDesc: []string{
+			"Error Number",
+			"[Echoed Message]",
+		},
+	},
+	SystemReset: {
+		Name:      "System Reset",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x4A},
+		Direction: HostToANT,
+		Reply:     false,
+		Section:   "9.5.4.1",
+		DataFieldDesc: []string{
+			"0",
+		},
+	},
+	OpenChannel: {
+		Name:      "Open Channel",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x4B},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.4.2",
+		DataFieldDesc: []string{
+			"Channel Number",
+		},
+	},
+	CloseChannel: {
+		Name:      "Close Channel",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x4C},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.4.3",
+		DataFieldDesc: []string{
+			"Channel Number",
+		},
+	},
+	OpenRxScanMode: {
+		Name:      "Open Rx Scan Mode",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x5B},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.4.5",
+		DataFieldDesc: []string{
+			"0",
+		},
+	},
+	RequestMessage: {
+		Name:      "Request Message",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x4D},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.4.4",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Message ID",
+		},
+	},
+	SleepMessage: {
+		Name:      "Sleep Message",
+		Class:     "Control",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0xC5},
+		Direction: HostToANT,
+		Reply:     false,
+		Section:   "9.5.4.6",
+		DataFieldDesc: []string{
+			"0",
+		},
+	},
+	BroadcastData: {
+		Name:      "Broadcast Data",
+		Class:     "Data",
+		Template:  AntpacketTemplate{DataLength: 9, MaxLength: 19, ID: 0x4E},
+		Direction: Bidirectional,
+		Reply:     false,
+		Section:   "9.5.5.1",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Data0",
+			"Data1",
+			"Data2",
+			"Data3",
+			"Data4",
+			"Data5",
+			"Data6",
+			"Data7",
+			"[Flag Byte]",
+		},
+	},
+	AcknowledgeData: {
+		Name:      "Acknowledge Data",
+		Class:     "Data",
+		Template:  AntpacketTemplate{DataLength: 9, MaxLength: 19, ID: 0x4F},
+		Direction: Bidirectional,
+		Reply:     false,
+		Section:   "9.5.5.2",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Data0",
+			"Data1",
+			"Data2",
+			"Data3",
+			"Data4",
+			"Data5",
+			"Data6",
+			"Data7",
+			"[Flag Byte]",
+		},
+	},
+	BurstTransferData: {
+		Name:      "Burst Transfer Data",
+		Class:     "Data",
+		Template:  AntpacketTemplate{DataLength: 9, MaxLength: 19, ID: 0x50},
+		Direction: Bidirectional,
+		Reply:     false,
+		Section:   "9.5.5.3",
+		DataFieldDesc: []string{
+			"Sequence/Channel Number",
+			"Data0",
+			"Data1",
+			"Data2",
+			"Data3",
+			"Data4",
+			"Data5",
+			"Data6",
+			"Data7",
+			"[Flag Byte]",
+		},
+	},
+	ChannelResponseOrEvent: {
+		Name:      "Channel Response / Event",
+		Class:     "Channel / Event Messages",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 3, ID: 0x40},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.6.1",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Message ID",
+			"Message Code",
+		},
+	},
+	ChannelStatus: {
+		Name:      "Channel Status",
+		Class:     "Requested Response",
+		Template:  AntpacketTemplate{DataLength: 2, MaxLength: 2, ID: 0x52},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.7.1",
+		DataFieldDesc: []string{
+			"Channel Number",
+			"Channel Status",
+		},
+	},
+	ANTVersion: {
+		Name:      "ANT Version",
+		Class:     "Requested Response",
+		Template:  AntpacketTemplate{DataLength: 11, MaxLength: 16, ID: 0x3E},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.7.3",
+		DataFieldDesc: []string{
+			"Ver0",
+			"Ver1",
+			"Ver2",
+			"Ver 3|Ver 4",
+			"Ver 5|Ver6",
+			"Ver7",
+			"Ver8",
+			"Ver9",
+			"Ver10",
+		},
+	},
+	Capabilities: {
+		Name:      "Capabilities",
+		Class:     "Requested Response",
+		Template:  AntpacketTemplate{DataLength: 4, MaxLength: 8, ID: 0x54},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.7.4",
+		DataFieldDesc: []string{
+			"Max Channels",
+			"Max Networks",
+			"Standard Options",
+			"Advanced Options",
+			"[Adv’ Options 2]",
+			"[Max SensRcore Channels]",
+			"[Adv’ Options 3]",
+			"[Adv’ Options 4]",
+		},
+	},
+	SerialNumber: {
+		Name:      "Serial Number",
+		Class:     "Requested Response",
+		Template:  AntpacketTemplate{DataLength: 4, MaxLength: 4, ID: 0x61},
+		Direction: ANTToHost,
+		Reply:     false,
+		Section:   "9.5.7.5",
+		DataFieldDesc: []string{
+			"Serial Number(1/4)",
+			"Serial Number(2/4)",
+			"Serial Number(3/4)",
+			"Serial Number(4/4)",
+		},
+	},
+	CWInit: {
+		Name:      "CW Init",
+		Class:     "Test",
+		Template:  AntpacketTemplate{DataLength: 1, MaxLength: 1, ID: 0x53},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.8.1",
+		DataFieldDesc: []string{
+			"0",
+		},
+	},
+	CWTest: {
+		Name:      "CW Test",
+		Class:     "Test",
+		Template:  AntpacketTemplate{DataLength: 3, MaxLength: 3, ID: 0x48},
+		Direction: HostToANT,
+		Reply:     true,
+		Section:   "9.5.8.2",
+		DataFieldDesc: []string{
+			"0",
+			"TX Power",
+			"RF Freq",
+		},
+	},
+}
+
+// directedClasses holds the class sent each way for ids shared by a host command
+// and a message from the stick.
+var directedClasses = map[Direction]map[byte]*MsgClass{
+	HostToANT: {
+		SetChannelID: {
+			Name:      "Set Channel ID",
+			Class:     "Config",
+			Template:  AntpacketTemplate{DataLength: 5, MaxLength: 5, ID: 0x51},
+			Direction: HostToANT,
+			Reply:     true,
+			Section:   "9.5.2.3",
+			DataFieldDesc: []string{
+				"Channel Number",
+				"Device number(1/2)",
+				"Device number(2/2)",
+				"Device Type ID",
+				"Trans. Type",
+			},
+		},
+	},
+	ANTToHost: {
+		ChannelID: {
+			Name:      "Channel ID",
+			Class:     "Requested Response",
+			Template:  AntpacketTemplate{DataLength: 5, MaxLength: 5, ID: 0x51},
+			Direction: ANTToHost,
+			Reply:     false,
+			Section:   "9.5.7.2",
+			DataFieldDesc: []string{
+				"Channel Number",
+				"Device number(1/2)",
+				"Device number(2/2)",
+				"Device Type ID",
+				"Man ID",
+			},
+		},
+	},
+}
@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"bytes"
+	"github.com/Fumon/go-ant/internal/msggen"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCatalogMatchesMessageTable(t *testing.T) {
+	table, err := os.Open("../message_types.csv")
+	if err != nil {
+		t.Fatal("Error opening message table, ", err)
+	}
+	defer table.Close()
+
+	generated, err := msggen.Generate(table, "protocol")
+	if err != nil {
+		t.Fatal("Error generating catalog, ", err)
+	}
+	current, err := os.ReadFile("catalog.go")
+	if err != nil {
+		t.Fatal("Error reading catalog, ", err)
+	}
+	if !bytes.Equal(generated, current) {
+		t.Fatal("catalog.go differs from message_types.csv, run go generate")
+	}
+}
+
+func TestCatalogDirections(t *testing.T) {
+	if MsgClasses[StartupMessage].Direction != ANTToHost || MsgClasses[OpenChannel].Direction != HostToANT {
+		t.Fatal("Directions not taken from the message table")
+	}
+	if MsgClasses[BroadcastData].Direction != Bidirectional || MsgClasses[ChannelID].Direction != Bidirectional {
+		t.Fatal("Messages sent both ways are not bidirectional")
+	}
+}
+
+func TestLookupByDirection(t *testing.T) {
+	sent, ok := Lookup(SetChannelID, HostToANT)
+	if !ok || sent.Name != "Set Channel ID" {
+		t.Fatal("Wrong class for sent 0x51, ", sent)
+	}
+	received, ok := Lookup(ChannelID, ANTToHost)
+	if !ok || received.Name != "Channel ID" {
+		t.Fatal("Wrong class for received 0x51, ", received)
+	}
+	if _, ok = Lookup(StartupMessage, HostToANT); ok {
+		t.Fatal("Found a startup message sent by the host")
+	}
+	if _, ok = Lookup(BroadcastData, ANTToHost); !ok {
+		t.Fatal("Broadcast data not found for either direction")
+	}
+
+	pkt, _ := GenerateAntpacket(SetChannelID, 1, 0x34, 0x12, 120, 1)
+	if s := pkt.Describe(ANTToHost); !strings.HasPrefix(s, "\"Channel ID\"") {
+		t.Fatal("Received 0x51 described as ", s)
+	}
+}
@@ -0,0 +1,21 @@
+package protocol
+
+type anterror string
+
+func (a anterror) Error() string {
+	return string(a)
+}
+
+// Errors
+const (
+	ErrArgumentsNil        = anterror("Arguments list must not be nil")
+	ErrArgumentsLen        = anterror("Arguments length mismatch")
+	ErrUnknownClass        = anterror("Unknown message class")
+	ErrMinimumPacketLength = anterror("Packet is smaller than minimum length")
+	ErrChecksumMismatch    = anterror("Checksum Mismatch")
+	ErrMissingSync         = anterror("Packet does not begin with sync byte")
+	ErrPacketTruncated     = anterror("Packet is shorter than its message length")
+	ErrUnexpectedMessage   = anterror("Packet is not of the expected message class")
+	ErrDataLength          = anterror("Packet data length is invalid for its message class")
+	ErrWrongDirection      = anterror("Message class is not sent in that direction")
+)
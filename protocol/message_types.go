@@ -0,0 +1,48 @@
+package protocol
+
+// The message ids and MsgClasses are generated from the message table.
+//go:generate go run ../cmd/msggen -in ../message_types.csv -out catalog.go
+
+// A Direction says who sends a message.
+type Direction byte
+
+// Message directions
+const (
+	HostToANT Direction = 1 << iota
+	ANTToHost
+	Bidirectional = HostToANT | ANTToHost
+)
+
+// MsgClass describes a message class of the ant protocol.
+//
+// DataFieldDesc may describe optional fields beyond the template's DataLength.
+type MsgClass struct {
+	Name      string
+	Class     string
+	Template  AntpacketTemplate
+	Direction Direction
+	// Reply is whether the spec says the message is answered
+	Reply bool
+	// Section of the ant message protocol and usage document
+	Section       string
+	DataFieldDesc []string
+}
+
+// HasChannel reports whether the first data byte of the message is a channel number.
+func (m *MsgClass) HasChannel() bool {
+	return len(m.DataFieldDesc) > 0 && m.DataFieldDesc[0] == "Channel Number"
+}
+
+// Lookup returns the class of message id as sent in direction dir, HostToANT
+// for packets the host sends and ANTToHost for those it receives. It fails for
+// messages never sent that way.
+func Lookup(id byte, dir Direction) (*MsgClass, bool) {
+	if c, ok := directedClasses[dir][id]; ok {
+		return c, true
+	}
+	c, ok := MsgClasses[id]
+	if !ok || c.Direction&dir == 0 {
+		return nil, false
+	}
+	return c, true
+}
@@ -0,0 +1,159 @@
+package protocol
+
+import (
+	"fmt"
+)
+
+// A ResponseCode is the message code of a ChannelResponseOrEvent packet.
+//
+// Codes are either events generated by the channel (EVENT_*) or responses to a
+// command sent by the host. Response codes other than RESPONSE_NO_ERROR report
+// that the command failed, and are usable as error values.
+type ResponseCode byte
+
+// Channel response and event codes
+const (
+	ResponseNoError             ResponseCode = 0x00
+	EventRxSearchTimeout        ResponseCode = 0x01
+	EventRxFail                 ResponseCode = 0x02
+	EventTx                     ResponseCode = 0x03
+	EventTransferRxFailed       ResponseCode = 0x04
+	EventTransferTxCompleted    ResponseCode = 0x05
+	EventTransferTxFailed       ResponseCode = 0x06
+	EventChannelClosed          ResponseCode = 0x07
+	EventRxFailGoToSearch       ResponseCode = 0x08
+	EventChannelCollision       ResponseCode = 0x09
+	EventTransferTxStart        ResponseCode = 0x0A
+	EventTransferNextDataBlock  ResponseCode = 0x11
+	ChannelInWrongState         ResponseCode = 0x15
+	ChannelNotOpened            ResponseCode = 0x16
+	ChannelIDNotSet             ResponseCode = 0x18
+	CloseAllChannels            ResponseCode = 0x19
+	TransferInProgress          ResponseCode = 0x1F
+	TransferSequenceNumberError ResponseCode = 0x20
+	TransferInError             ResponseCode = 0x21
+	MessageSizeExceedsLimit     ResponseCode = 0x27
+	InvalidMessage              ResponseCode = 0x28
+	InvalidNetworkNumber        ResponseCode = 0x29
+	InvalidListID               ResponseCode = 0x30
+	InvalidScanTxChannel        ResponseCode = 0x31
+	InvalidParameterProvided    ResponseCode = 0x33
+	EventSerialQueOverflow      ResponseCode = 0x34
+	EventQueOverflow            ResponseCode = 0x35
+	EncryptNegotiationSuccess   ResponseCode = 0x38
+	EncryptNegotiationFail      ResponseCode = 0x39
+	NVMFullError                ResponseCode = 0x40
+	NVMWriteError               ResponseCode = 0x41
+	USBStringWriteFail          ResponseCode = 0x70
+	MesgSerialErrorID           ResponseCode = 0xAE
+)
+
+type responseCodeInfo struct {
+	name  string
+	event bool
+}
+
+var responseCodes = map[ResponseCode]responseCodeInfo{
+	ResponseNoError:             {"RESPONSE_NO_ERROR", false},
+	EventRxSearchTimeout:        {"EVENT_RX_SEARCH_TIMEOUT", true},
+	EventRxFail:                 {"EVENT_RX_FAIL", true},
+	EventTx:                     {"EVENT_TX", true},
+	EventTransferRxFailed:       {"EVENT_TRANSFER_RX_FAILED", true},
+	EventTransferTxCompleted:    {"EVENT_TRANSFER_TX_COMPLETED", true},
+	EventTransferTxFailed:       {"EVENT_TRANSFER_TX_FAILED", true},
+	EventChannelClosed:          {"EVENT_CHANNEL_CLOSED", true},
+	EventRxFailGoToSearch:       {"EVENT_RX_FAIL_GO_TO_SEARCH", true},
+	EventChannelCollision:       {"EVENT_CHANNEL_COLLISION", true},
+	EventTransferTxStart:        {"EVENT_TRANSFER_TX_START", true},
+	EventTransferNextDataBlock:  {"EVENT_TRANSFER_NEXT_DATA_BLOCK", true},
+	ChannelInWrongState:         {"CHANNEL_IN_WRONG_STATE", false},
+	ChannelNotOpened:            {"CHANNEL_NOT_OPENED", false},
+	ChannelIDNotSet:             {"CHANNEL_ID_NOT_SET", false},
+	CloseAllChannels:            {"CLOSE_ALL_CHANNELS", false},
+	TransferInProgress:          {"TRANSFER_IN_PROGRESS", false},
+	TransferSequenceNumberError: {"TRANSFER_SEQUENCE_NUMBER_ERROR", false},
+	TransferInError:             {"TRANSFER_IN_ERROR", false},
+	MessageSizeExceedsLimit:     {"MESSAGE_SIZE_EXCEEDS_LIMIT", false},
+	InvalidMessage:              {"INVALID_MESSAGE", false},
+	InvalidNetworkNumber:        {"INVALID_NETWORK_NUMBER", false},
+	InvalidListID:               {"INVALID_LIST_ID", false},
+	InvalidScanTxChannel:        {"INVALID_SCAN_TX_CHANNEL", false},
+	InvalidParameterProvided:    {"INVALID_PARAMETER_PROVIDED", false},
+	EventSerialQueOverflow:      {"EVENT_SERIAL_QUE_OVERFLOW", true},
+	EventQueOverflow:            {"EVENT_QUE_OVERFLOW", true},
+	EncryptNegotiationSuccess:   {"ENCRYPT_NEGOTIATION_SUCCESS", true},
+	EncryptNegotiationFail:      {"ENCRYPT_NEGOTIATION_FAIL", true},
+	NVMFullError:                {"NVM_FULL_ERROR", false},
+	NVMWriteError:               {"NVM_WRITE_ERROR", false},
+	USBStringWriteFail:          {"USB_STRING_WRITE_FAIL", false},
+	MesgSerialErrorID:           {"MESG_SERIAL_ERROR_ID", false},
+}
+
+func (c ResponseCode) String() string {
+	if info, ok := responseCodes[c]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("UNKNOWN_RESPONSE_CODE(0x%02X)", byte(c))
+}
+
+// IsEvent reports whether the code is an event generated by a channel.
+func (c ResponseCode) IsEvent() bool {
+	return responseCodes[c].event
+}
+
+// IsError reports whether the code is a failed response to a command.
+// Unknown codes are treated as errors.
+func (c ResponseCode) IsError() bool {
+	return c != ResponseNoError && !c.IsEvent()
+}
+
+// Error makes ResponseCode usable as an error value, so that
+// errors.Is(err, ChannelInWrongState) works on errors returned by the Antbuffer.
+func (c ResponseCode) Error() string {
+	return c.String()
+}
+
+// Err returns the code as an error if it is an error code, otherwise nil.
+func (c ResponseCode) Err() error {
+	if c.IsError() {
+		return c
+	}
+	return nil
+}
+
+// A ChannelResponse is a decoded ChannelResponseOrEvent packet.
+//
+// MessageID is the id of the command being responded to, or 0x01 when the
+// packet is an event generated by the channel.
+type ChannelResponse struct {
+	Channel   byte
+	MessageID byte
+	Code      ResponseCode
+}
+
+// IsEvent reports whether the packet is a channel event rather than a response to a command.
+func (r *ChannelResponse) IsEvent() bool {
+	return r.MessageID == 0x01
+}
+
+func (r *ChannelResponse) String() string {
+	if r.IsEvent() {
+		return fmt.Sprintf("Channel %d: %v", r.Channel, r.Code)
+	}
+	name := fmt.Sprintf("0x%02X", r.MessageID)
+	if c, ok := MsgClasses[r.MessageID]; ok {
+		name = c.Name
+	}
+	return fmt.Sprintf("Channel %d: \"%s\" %v", r.Channel, name, r.Code)
+}
+
+// DecodeChannelResponse decodes a ChannelResponseOrEvent packet.
+func DecodeChannelResponse(pkt *Antpacket) (*ChannelResponse, error) {
+	if pkt.ID != ChannelResponseOrEvent {
+		return nil, ErrUnexpectedMessage
+	}
+	if len(pkt.Data) < 3 {
+		return nil, ErrMinimumPacketLength
+	}
+	return &ChannelResponse{pkt.Data[0], pkt.Data[1], ResponseCode(pkt.Data[2])}, nil
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResponseCodeString(t *testing.T) {
+	if EventChannelClosed.String() != "EVENT_CHANNEL_CLOSED" {
+		t.Fail()
+	}
+	if ResponseCode(0xEE).String() != "UNKNOWN_RESPONSE_CODE(0xEE)" {
+		t.Fail()
+	}
+}
+
+func TestResponseCodeClassification(t *testing.T) {
+	if ResponseNoError.IsEvent() || ResponseNoError.IsError() || ResponseNoError.Err() != nil {
+		t.Fatal("RESPONSE_NO_ERROR misclassified")
+	}
+	if !EventRxSearchTimeout.IsEvent() || EventRxSearchTimeout.IsError() || EventRxSearchTimeout.Err() != nil {
+		t.Fatal("EVENT_RX_SEARCH_TIMEOUT misclassified")
+	}
+	if InvalidMessage.IsEvent() || !InvalidMessage.IsError() {
+		t.Fatal("INVALID_MESSAGE misclassified")
+	}
+	if !errors.Is(InvalidMessage.Err(), InvalidMessage) {
+		t.Fatal("Error code not usable with errors.Is")
+	}
+}
+
+func TestDecodeChannelResponse(t *testing.T) {
+	pkt, _ := GenerateAntpacket(ChannelResponseOrEvent, 0x01, 0x01, byte(EventChannelClosed))
+	resp, err := DecodeChannelResponse(pkt)
+	if err != nil {
+		t.Fatal("Error decoding response, ", err)
+	}
+	if resp.Channel != 0x01 || !resp.IsEvent() || resp.Code != EventChannelClosed {
+		t.Fatal("Unexpected decode, ", resp)
+	}
+
+	pkt, _ = GenerateAntpacket(StartupMessage, 0x00)
+	if _, err = DecodeChannelResponse(pkt); err != ErrUnexpectedMessage {
+		t.Fatal("Decoded a packet of the wrong class")
+	}
+}
@@ -0,0 +1,70 @@
+package protocol
+
+import (
+	"fmt"
+)
+
+// A SerialErrorCode is the error number of a SerialErrorMessage.
+type SerialErrorCode byte
+
+// Serial error numbers
+const (
+	SerialErrorSync     SerialErrorCode = 0x00
+	SerialErrorChecksum SerialErrorCode = 0x02
+	SerialErrorTooLarge SerialErrorCode = 0x03
+)
+
+func (c SerialErrorCode) String() string {
+	switch c {
+	case SerialErrorSync:
+		return "SERIAL_ERROR_INCORRECT_SYNC"
+	case SerialErrorChecksum:
+		return "SERIAL_ERROR_CHECKSUM"
+	case SerialErrorTooLarge:
+		return "SERIAL_ERROR_MESSAGE_TOO_LARGE"
+	}
+	return fmt.Sprintf("UNKNOWN_SERIAL_ERROR(0x%02X)", byte(c))
+}
+
+// A SerialError is a decoded SerialErrorMessage, sent by the stick when it
+// could not read a message from the host.
+type SerialError struct {
+	Code SerialErrorCode
+	// Message is whatever part of the offending message the stick echoed back
+	Message []byte
+}
+
+func (s *SerialError) Error() string {
+	return fmt.Sprintf("ant stick could not read message: %v", s.Code)
+}
+
+// Echoed returns the id and as much data as the stick echoed back of the
+// message it could not read, which may have been cut short. The echo may or
+// may not start with the sync byte; no message length is long enough to be
+// taken for one. ok is false when too little was echoed to know the message.
+func (s *SerialError) Echoed() (id byte, data []byte, ok bool) {
+	echo := s.Message
+	if len(echo) > 0 && echo[0] == SyncByte {
+		echo = echo[1:]
+	}
+	if len(echo) < 2 {
+		return 0, nil, false
+	}
+	msgLen, id, data := int(echo[0]), echo[1], echo[2:]
+	if len(data) > msgLen {
+		// Drop the checksum
+		data = data[:msgLen]
+	}
+	return id, data, true
+}
+
+// DecodeSerialError decodes a SerialErrorMessage packet.
+func DecodeSerialError(pkt *Antpacket) (*SerialError, error) {
+	if pkt.ID != SerialErrorMessage {
+		return nil, ErrUnexpectedMessage
+	}
+	if len(pkt.Data) < 1 {
+		return nil, ErrMinimumPacketLength
+	}
+	return &SerialError{SerialErrorCode(pkt.Data[0]), pkt.Data[1:]}, nil
+}
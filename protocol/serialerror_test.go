@@ -0,0 +1,37 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestDecodeSerialError(t *testing.T) {
+	pkt := &Antpacket{SyncByte, 3, SerialErrorMessage, []byte{byte(SerialErrorChecksum), 0xA4, 0x01}, 0}
+	serr, err := DecodeSerialError(pkt)
+	if err != nil {
+		t.Fatal("Error decoding serial error, ", err)
+	}
+	if serr.Code != SerialErrorChecksum || len(serr.Message) != 2 {
+		t.Fatal("Unexpected decode, ", serr)
+	}
+	if serr.Code.String() != "SERIAL_ERROR_CHECKSUM" || SerialErrorCode(0x07).String() != "UNKNOWN_SERIAL_ERROR(0x07)" {
+		t.Fatal("Unexpected names, ", serr.Code)
+	}
+
+	if _, _, ok := serr.Echoed(); ok {
+		t.Fatal("Found the message in a two byte echo")
+	}
+
+	// With or without the sync byte
+	for _, echo := range [][]byte{{0xA4, 0x01, 0x4B, 0x02, 0xEF}, {0x02, 0x4D, 0x01}} {
+		serr = &SerialError{SerialErrorChecksum, echo}
+		id, data, ok := serr.Echoed()
+		if !ok || (id != OpenChannel && id != RequestMessage) || len(data) != 1 {
+			t.Fatalf("Echo % X gave 0x%02X % X %v", echo, id, data, ok)
+		}
+	}
+
+	pkt, _ = GenerateAntpacket(StartupMessage, 0x00)
+	if _, err = DecodeSerialError(pkt); err != ErrUnexpectedMessage {
+		t.Fatal("Decoded a packet of the wrong class")
+	}
+}
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"strings"
+)
+
+// A StartupReason is the bit field of a StartupMessage telling why the stick started.
+type StartupReason byte
+
+// Startup reasons. A power on reset sets no bits.
+const (
+	PowerOnReset      StartupReason = 0x00
+	HardwareResetLine StartupReason = 0x01
+	WatchdogReset     StartupReason = 0x02
+	CommandReset      StartupReason = 0x20
+	SynchronousReset  StartupReason = 0x40
+	SuspendReset      StartupReason = 0x80
+)
+
+var startupReasonNames = []struct {
+	reason StartupReason
+	name   string
+}{
+	{HardwareResetLine, "HARDWARE_RESET_LINE"},
+	{WatchdogReset, "WATCH_DOG_RESET"},
+	{CommandReset, "COMMAND_RESET"},
+	{SynchronousReset, "SYNCHRONOUS_RESET"},
+	{SuspendReset, "SUSPEND_RESET"},
+}
+
+// Has reports whether the reason bit r is set. PowerOnReset is had only when no bit is set.
+func (s StartupReason) Has(r StartupReason) bool {
+	if r == PowerOnReset {
+		return s == PowerOnReset
+	}
+	return s&r == r
+}
+
+func (s StartupReason) String() string {
+	if s == PowerOnReset {
+		return "POWER_ON_RESET"
+	}
+
+	var names []string
+	for _, r := range startupReasonNames {
+		if s.Has(r.reason) {
+			names = append(names, r.name)
+		}
+	}
+	if len(names) == 0 {
+		return "UNKNOWN_RESET"
+	}
+	return strings.Join(names, "|")
+}
+
+// DecodeStartupMessage returns the reason carried by a StartupMessage packet.
+func DecodeStartupMessage(pkt *Antpacket) (StartupReason, error) {
+	if pkt.ID != StartupMessage {
+		return 0, ErrUnexpectedMessage
+	}
+	if len(pkt.Data) < 1 {
+		return 0, ErrMinimumPacketLength
+	}
+	return StartupReason(pkt.Data[0]), nil
+}
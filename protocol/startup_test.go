@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestDecodeStartupMessage(t *testing.T) {
+	pkt, _ := GenerateAntpacket(StartupMessage, 0x22)
+	reason, err := DecodeStartupMessage(pkt)
+	if err != nil {
+		t.Fatal("Error decoding startup message, ", err)
+	}
+	if !reason.Has(CommandReset) || !reason.Has(WatchdogReset) || reason.Has(PowerOnReset) || reason.Has(SuspendReset) {
+		t.Fatal("Unexpected reason bits, ", reason)
+	}
+	if reason.String() != "WATCH_DOG_RESET|COMMAND_RESET" {
+		t.Fatal("Unexpected name, ", reason)
+	}
+	if !PowerOnReset.Has(PowerOnReset) || PowerOnReset.String() != "POWER_ON_RESET" {
+		t.Fatal("Power on reset misclassified")
+	}
+
+	pkt, _ = GenerateAntpacket(ChannelResponseOrEvent, 0x01, 0x01, 0x07)
+	if _, err = DecodeStartupMessage(pkt); err != ErrUnexpectedMessage {
+		t.Fatal("Decoded a packet of the wrong class")
+	}
+}
@@ -0,0 +1,12 @@
+package usbtransport
+
+type anterror string
+
+func (a anterror) Error() string {
+	return string(a)
+}
+
+// Errors
+const (
+	ErrNoDevice = anterror("No ant stick found")
+)
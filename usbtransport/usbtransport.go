@@ -0,0 +1,161 @@
+// Package usbtransport connects the ant driver to USB ant sticks through gousb.
+package usbtransport
+
+import (
+	"fmt"
+	"github.com/Fumon/go-ant/ant"
+	"github.com/Fumon/go-ant/protocol"
+	"github.com/yokujin/gousb/usb"
+	"sync"
+)
+
+// USB identifiers of the Dynastream ant stick
+const (
+	DynastreamUsbVendid = 0x0fcf
+	Antstick            = 0x1008
+)
+
+// USB endpoint info
+const (
+	uconf  = 01
+	uiface = 00
+	usetup = 00
+	uep    = 0x01
+)
+
+// usbTransport is a Transport over a pair of gousb bulk endpoints.
+type usbTransport struct {
+	device *usb.Device
+	epin   usb.Endpoint
+	epout  usb.Endpoint
+	closed chan struct{}
+	once   sync.Once
+}
+
+// New creates a Transport reading from epin and writing to epout.
+// The endpoints remain owned by the caller.
+func New(epin, epout usb.Endpoint) ant.Transport {
+	return &usbTransport{
+		epin:   epin,
+		epout:  epout,
+		closed: make(chan struct{}),
+	}
+}
+
+// Open finds the first Dynastream ant stick attached and opens its bulk endpoints.
+// The device is closed when the returned Transport is closed.
+func Open(ctx *usb.Context) (ant.Transport, error) {
+	// Find and open the device
+	devs, err := ctx.ListDevices(func(desc *usb.Descriptor) bool {
+		return desc.Vendor == DynastreamUsbVendid && desc.Product == Antstick
+	})
+	if err != nil {
+		for _, d := range devs {
+			d.Close()
+		}
+		return nil, err
+	}
+
+	// Exit if no devices opened
+	if len(devs) == 0 {
+		return nil, ErrNoDevice
+	}
+
+	// Pick off the first device
+	antdev := devs[0]
+	for _, d := range devs[1:] {
+		d.Close()
+	}
+
+	epin, err := antdev.OpenEndpoint(
+		uconf,
+		uiface,
+		usetup,
+		uint8(uep)|uint8(usb.ENDPOINT_DIR_IN),
+	)
+	if err != nil {
+		antdev.Close()
+		return nil, err
+	}
+	epout, err := antdev.OpenEndpoint(
+		uconf,
+		uiface,
+		usetup,
+		uint8(uep)|uint8(usb.ENDPOINT_DIR_OUT),
+	)
+	if err != nil {
+		antdev.Close()
+		return nil, err
+	}
+
+	return &usbTransport{
+		device: antdev,
+		epin:   epin,
+		epout:  epout,
+		closed: make(chan struct{}),
+	}, nil
+}
+
+func (u *usbTransport) ReadFrame() ([]byte, error) {
+	if u.isClosed() {
+		return nil, ant.ErrTransportClosed
+	}
+
+	buf := make([]byte, protocol.MaxDataLength)
+	n, err := u.epin.Read(buf)
+	if err == usb.ERROR_TIMEOUT {
+		return nil, ant.ErrTransportTimeout
+	} else if err != nil {
+		return nil, usbError(err)
+	}
+	return buf[:n], nil
+}
+
+func (u *usbTransport) WriteFrame(frame []byte) error {
+	if u.isClosed() {
+		return ant.ErrTransportClosed
+	}
+
+	_, err := u.epout.Write(frame)
+	if err != nil {
+		return usbError(err)
+	}
+	return nil
+}
+
+// usbError marks errors meaning the stick has been unplugged.
+func usbError(err error) error {
+	if err == usb.ERROR_NO_DEVICE {
+		return fmt.Errorf("%w: %v", ant.ErrDeviceGone, err)
+	}
+	return err
+}
+
+// Close marks the transport closed, and closes the usb device if it was opened by Open.
+func (u *usbTransport) Close() error {
+	var err error
+	u.once.Do(func() {
+		close(u.closed)
+		if u.device != nil {
+			err = u.device.Close()
+		}
+	})
+	return err
+}
+
+// USBIDs returns the vendor and product ids of the stick, or zeros if it was not opened by Open.
+func (u *usbTransport) USBIDs() (vendor, product uint16) {
+	if u.device == nil || u.device.Descriptor == nil {
+		return 0, 0
+	}
+	return uint16(u.device.Vendor), uint16(u.device.Product)
+}
+
+func (u *usbTransport) isClosed() bool {
+	select {
+	case <-u.closed:
+		return true
+	default:
+		return false
+	}
+}